@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
@@ -41,9 +42,11 @@ var dedupeCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Printf("\n✅ Duplicates removed. Output written to %s\n", dedupeOutput)
-		fmt.Printf("📊 Total rows: %d | Unique: %d | Duplicates removed: %d\n", res.TotalRows, res.UniqueRows, res.Duplicates)
-		return nil
+		return emitResult(os.Stdout, res, func() error {
+			fmt.Printf("\n✅ Duplicates removed. Output written to %s\n", dedupeOutput)
+			fmt.Printf("📊 Total rows: %d | Unique: %d | Duplicates removed: %d\n", res.TotalRows, res.UniqueRows, res.Duplicates)
+			return nil
+		})
 	},
 }
 
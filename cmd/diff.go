@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffOld              string
+	diffNew              string
+	diffKey              string
+	diffIgnore           string
+	diffOutput           string
+	diffIncludeUnchanged bool
+	diffMaxChanges       int
+	diffMemoryRows       int
+	diffNoColor          bool
+	diffDelimiter        string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two CSV files by key: added, removed and changed rows",
+	Long: `Compare two versions of a CSV file (e.g. yesterday's and today's export)
+by one or more key columns and report added, removed and changed rows, with
+the old and new value of every changed cell.
+
+Memory stays bounded: if the smaller file has at most --memory-rows rows it
+is held in a hash table while the larger one is streamed; otherwise both are
+sorted by key on disk and merged.
+
+--output writes a unified diff CSV with a leading _change column (added,
+removed, changed) in which changed cells read "old → new". --format json
+prints the full summary. The command exits with status 1 when the files
+differ, so it can gate CI jobs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(diffDelimiter)
+		if err != nil {
+			return err
+		}
+
+		opts := csvops.DiffOptions{
+			Old:              diffOld,
+			New:              diffNew,
+			KeyColumns:       splitColumns(diffKey),
+			IgnoreColumns:    splitColumns(diffIgnore),
+			IncludeUnchanged: diffIncludeUnchanged,
+			MaxChanges:       diffMaxChanges,
+			MemoryRows:       diffMemoryRows,
+			Delimiter:        delim,
+		}
+		if diffOutput != "" {
+			f, err := os.Create(diffOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			opts.Output = f
+		}
+
+		var bar *progressbar.ProgressBar
+		opts.Progress = func(done, total int64) {
+			if bar == nil {
+				bar = progressbar.Default(total, "Diffing")
+			}
+			_ = bar.Set64(done)
+		}
+
+		res, err := csvops.Diff(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+
+		headers := []string{"change", "key", "column", "old", "new"}
+		var rows [][]string
+		for _, c := range res.Changes {
+			key := strings.Join(c.Key, "|")
+			if len(c.Cells) == 0 {
+				rows = append(rows, []string{c.Change, key, "", "", ""})
+			}
+			for _, cell := range c.Cells {
+				rows = append(rows, []string{c.Change, key, cell.Column, cell.Old, cell.New})
+			}
+		}
+		if err := emitTable(os.Stdout, res, headers, rows, func() error {
+			printDiff(os.Stdout, res, opts.KeyColumns, useColor(os.Stdout) && !diffNoColor)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if !res.Identical {
+			return fmt.Errorf("files differ: %d added, %d removed, %d changed", res.Added, res.Removed, res.Changed)
+		}
+		return nil
+	},
+}
+
+// ANSI colors for the diff view.
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiDim    = "\x1b[2m"
+	ansiReset  = "\x1b[0m"
+)
+
+// useColor reports whether w is a terminal and NO_COLOR is unset.
+func useColor(w *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := w.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func printDiff(w io.Writer, res csvops.DiffResult, keys []string, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	keyLabel := func(values []string) string {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = keys[i] + "=" + v
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	fmt.Fprintf(w, "\n🔍 %s → %s (%s)\n", diffOld, diffNew, res.Strategy)
+	if len(res.AddedColumns) > 0 {
+		fmt.Fprintln(w, paint(ansiGreen, "+ columns: "+strings.Join(res.AddedColumns, ", ")))
+	}
+	if len(res.RemovedColumns) > 0 {
+		fmt.Fprintln(w, paint(ansiRed, "- columns: "+strings.Join(res.RemovedColumns, ", ")))
+	}
+	if len(res.Changes) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, c := range res.Changes {
+		switch c.Change {
+		case csvops.DiffAdded:
+			fmt.Fprintln(w, paint(ansiGreen, "+ "+keyLabel(c.Key)))
+		case csvops.DiffRemoved:
+			fmt.Fprintln(w, paint(ansiRed, "- "+keyLabel(c.Key)))
+		case csvops.DiffChanged:
+			fmt.Fprintln(w, paint(ansiYellow, "~ "+keyLabel(c.Key)))
+			for _, cell := range c.Cells {
+				fmt.Fprintf(w, "    %s: %s → %s\n", cell.Column, paint(ansiRed, cell.Old), paint(ansiGreen, cell.New))
+			}
+		}
+	}
+	if hidden := res.Added + res.Removed + res.Changed - int64(len(res.Changes)); hidden > 0 {
+		fmt.Fprintln(w, paint(ansiDim, fmt.Sprintf("… and %d more (raise --max-changes to see them)", hidden)))
+	}
+
+	if len(res.ChangedCells) > 0 {
+		cols := make([]string, 0, len(res.ChangedCells))
+		for c := range res.ChangedCells {
+			cols = append(cols, c)
+		}
+		sort.Strings(cols)
+		fmt.Fprintln(w, "\nChanged cells by column:")
+		for _, c := range cols {
+			fmt.Fprintf(w, "  %-20s %d\n", c, res.ChangedCells[c])
+		}
+	}
+	if res.DuplicateKeys > 0 {
+		fmt.Fprintf(w, "\n⚠️  %d rows repeat a key already seen in the same file; they are paired in file order, and any extra are reported as added or removed.\n", res.DuplicateKeys)
+	}
+
+	if res.Identical {
+		fmt.Fprintf(w, "\n✅ No differences (%d rows).\n", res.Unchanged)
+	} else {
+		fmt.Fprintf(w, "\n📋 %s added, %s removed, %s changed, %d unchanged.\n",
+			paint(ansiGreen, fmt.Sprint(res.Added)), paint(ansiRed, fmt.Sprint(res.Removed)), paint(ansiYellow, fmt.Sprint(res.Changed)), res.Unchanged)
+	}
+	if diffOutput != "" {
+		fmt.Fprintf(w, "📄 Unified diff written to %s\n", diffOutput)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffOld, "old", "", "Old (baseline) CSV file (required)")
+	diffCmd.Flags().StringVar(&diffNew, "new", "", "New CSV file (required)")
+	diffCmd.Flags().StringVar(&diffKey, "key", "", "Comma-separated key columns that identify a row (required)")
+	diffCmd.Flags().StringVar(&diffIgnore, "ignore", "", "Comma-separated columns to leave out of the comparison")
+	diffCmd.Flags().StringVar(&diffOutput, "output", "", "Write a unified diff CSV with a _change column")
+	diffCmd.Flags().BoolVar(&diffIncludeUnchanged, "include-unchanged", false, "Also write unchanged rows to --output")
+	diffCmd.Flags().IntVar(&diffMaxChanges, "max-changes", 50, "Max changed rows listed in the report (0 = all); all are still counted")
+	diffCmd.Flags().IntVar(&diffMemoryRows, "memory-rows", 1_000_000, "Rows held in memory before switching to an on-disk sort-merge")
+	diffCmd.Flags().BoolVar(&diffNoColor, "no-color", false, "Disable colored output")
+	diffCmd.Flags().StringVar(&diffDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = diffCmd.MarkFlagRequired("old")
+	_ = diffCmd.MarkFlagRequired("new")
+	_ = diffCmd.MarkFlagRequired("key")
+}
@@ -76,8 +76,10 @@ so matching empty strings via --eq="" works correctly.`,
 			return err
 		}
 
-		fmt.Fprintf(os.Stderr, "\n✅ Filter complete. %d rows matched out of %d total.\n", res.Matched, res.TotalRows)
-		return nil
+		return emitResult(resultWriter(filterOutput), res, func() error {
+			fmt.Fprintf(os.Stderr, "\n✅ Filter complete. %d rows matched out of %d total.\n", res.Matched, res.TotalRows)
+			return nil
+		})
 	},
 }
 
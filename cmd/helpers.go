@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
@@ -27,3 +30,51 @@ func parseDelimiter(s string) (rune, error) {
 func countDataRows(path string, delim rune) (int64, error) {
 	return csvops.CountDataRows(path, delim)
 }
+
+// splitColumns turns a comma-separated flag value into trimmed, non-empty names.
+func splitColumns(s string) []string {
+	var out []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// parseByteSize parses sizes like "500", "64KB", "100MB" or "1.5GiB".
+// Decimal (KB, MB, GB) and binary (KiB, MiB, GiB) suffixes are accepted,
+// case-insensitively. Fractions need a unit, and the size must come to at
+// least one byte.
+func parseByteSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+		{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+		{"k", 1e3}, {"m", 1e6}, {"g", 1e9},
+		{"b", 1},
+	}
+	lower := strings.ToLower(strings.TrimSpace(s))
+	mult := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(lower, u.suffix) {
+			lower = strings.TrimSpace(strings.TrimSuffix(lower, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(lower, 64)
+	if err != nil || n <= 0 || n*mult >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500KB, 100MB)", s)
+	}
+	if mult == 1 && n != math.Trunc(n) {
+		return 0, fmt.Errorf("invalid size %q: fractional sizes need a unit, e.g. 1.5MB", s)
+	}
+	size := int64(n * mult)
+	if size < 1 {
+		return 0, fmt.Errorf("invalid size %q: must be at least 1 byte", s)
+	}
+	return size, nil
+}
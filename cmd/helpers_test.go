@@ -78,3 +78,47 @@ func TestCountDataRowsHeaderOnly(t *testing.T) {
 		t.Fatalf("countDataRows header-only = %d, want 0", got)
 	}
 }
+
+func TestSplitColumns(t *testing.T) {
+	got := splitColumns(" country, city ,,")
+	if len(got) != 2 || got[0] != "country" || got[1] != "city" {
+		t.Fatalf("splitColumns = %q", got)
+	}
+	if got := splitColumns(""); got != nil {
+		t.Fatalf("splitColumns(\"\") = %q, want nil", got)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"500", 500, false},
+		{"64KB", 64000, false},
+		{"100MB", 100000000, false},
+		{"100mb", 100000000, false},
+		{"1.5GiB", 1610612736, false},
+		{"2 MiB", 2097152, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-5MB", 0, true},
+		{"0.5", 0, true},
+		{"1.5", 0, true},
+		{"1.5B", 0, true},
+		{"0.0001KB", 0, true},
+		{"0.5KB", 500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseByteSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("parseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	indexInput     string
+	indexEvery     int
+	indexDelimiter string
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build a row offset index (.csvidx) for fast random access",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(indexDelimiter)
+		if err != nil {
+			return err
+		}
+
+		var bar *progressbar.ProgressBar
+		idx, err := csvops.BuildIndex(context.Background(), csvops.IndexOptions{
+			Input:     indexInput,
+			Every:     indexEvery,
+			Delimiter: delim,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.DefaultBytes(total, "Indexing")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		summary := struct {
+			Path    string `json:"path"`
+			Rows    int64  `json:"rows"`
+			Every   int    `json:"every"`
+			Offsets int    `json:"offsets"`
+		}{indexInput + csvops.IndexExt, idx.Rows, idx.Every, len(idx.Offsets)}
+		return emitResult(os.Stdout, summary, func() error {
+			fmt.Printf("\n✅ Indexed %d rows (%d offsets) into %s\n", idx.Rows, len(idx.Offsets), summary.Path)
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+
+	indexCmd.Flags().StringVar(&indexInput, "input", "", "Input CSV file path (required)")
+	indexCmd.Flags().IntVar(&indexEvery, "every", csvops.DefaultIndexInterval, "Record an offset every N rows")
+	indexCmd.Flags().StringVar(&indexDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = indexCmd.MarkFlagRequired("input")
+}
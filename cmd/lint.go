@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/olekukonko/tablewriter"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintInput     string
+	lintFix       bool
+	lintOutput    string
+	lintMaxIssues int
+	lintDelimiter string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report structural problems in a CSV file and optionally fix them",
+	Long: `Scan a CSV file byte by byte and report structural problems that other
+commands silently tolerate: inconsistent field counts, trailing delimiters,
+bare and unterminated quotes, mixed line endings, empty or duplicate header
+names, invisible characters (no-break space, zero-width space, BOM) and
+invalid UTF-8. Each issue is reported with its line and byte offset.
+
+The command exits with status 1 when issues are found. --fix instead writes
+a normalized copy (to --output, or stdout) and exits 0.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(lintDelimiter)
+		if err != nil {
+			return err
+		}
+		if lintOutput != "" && !lintFix {
+			return fmt.Errorf("--output requires --fix")
+		}
+
+		opts := csvops.LintOptions{
+			Input:     lintInput,
+			Delimiter: delim,
+			MaxIssues: lintMaxIssues,
+		}
+		// The report goes to stderr when the fixed file is streamed to stdout.
+		report := io.Writer(os.Stdout)
+		if lintFix {
+			opts.Output = os.Stdout
+			if lintOutput != "" {
+				f, err := os.Create(lintOutput)
+				if err != nil {
+					return fmt.Errorf("create output: %w", err)
+				}
+				defer f.Close()
+				opts.Output = f
+			} else {
+				report = os.Stderr
+			}
+		}
+
+		var bar *progressbar.ProgressBar
+		opts.Progress = func(done, total int64) {
+			if bar == nil {
+				bar = progressbar.DefaultBytes(total, "Linting")
+			}
+			_ = bar.Set64(done)
+		}
+
+		res, err := csvops.Lint(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+
+		headers := []string{"line", "offset", "kind", "message"}
+		rows := make([][]string, len(res.Issues))
+		for i, is := range res.Issues {
+			rows[i] = []string{strconv.FormatInt(is.Line, 10), strconv.FormatInt(is.Offset, 10), is.Kind, is.Message}
+		}
+		if err := emitTable(report, res, headers, rows, func() error {
+			printLint(report, res)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if !res.Clean && !lintFix {
+			return fmt.Errorf("lint found %d issues", res.IssueCount)
+		}
+		return nil
+	},
+}
+
+func printLint(w io.Writer, res csvops.LintResult) {
+	if len(res.Issues) > 0 {
+		fmt.Fprintf(w, "\n🔎 Issues in %s:\n", lintInput)
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Line", "Offset", "Kind", "Message"})
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		for _, is := range res.Issues {
+			table.Append([]string{strconv.FormatInt(is.Line, 10), strconv.FormatInt(is.Offset, 10), is.Kind, is.Message})
+		}
+		table.Render()
+		if hidden := res.IssueCount - int64(len(res.Issues)); hidden > 0 {
+			fmt.Fprintf(w, "… and %d more (raise --max-issues to see them)\n", hidden)
+		}
+
+		kinds := make([]string, 0, len(res.Counts))
+		for k := range res.Counts {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		fmt.Fprintln(w, "\nBy kind:")
+		for _, k := range kinds {
+			fmt.Fprintf(w, "  %-20s %d\n", k, res.Counts[k])
+		}
+	}
+
+	if res.Clean {
+		fmt.Fprintf(w, "\n✅ %s is clean. %d rows, %s line endings.\n", lintInput, res.Rows, res.LineEnding)
+	} else {
+		fmt.Fprintf(w, "\n📋 %d issues in %d rows.\n", res.IssueCount, res.Rows)
+	}
+	if lintFix && lintOutput != "" {
+		fmt.Fprintf(w, "🛠  Fixed file written to %s\n", lintOutput)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVar(&lintInput, "input", "", "Input CSV file path (required)")
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Write a normalized copy of the file")
+	lintCmd.Flags().StringVar(&lintOutput, "output", "", "Fixed file path (with --fix; default is stdout)")
+	lintCmd.Flags().IntVar(&lintMaxIssues, "max-issues", 1000, "Max issues listed in the report (0 = all); all are still counted")
+	lintCmd.Flags().StringVar(&lintDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = lintCmd.MarkFlagRequired("input")
+}
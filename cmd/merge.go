@@ -5,22 +5,61 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/olekukonko/tablewriter"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
 var (
 	mergeInputDir   string
+	mergeInput      string
+	mergeRecursive  bool
 	mergeOutput     string
 	mergeWithHeader bool
+	mergeMode       string
+	mergeSource     bool
+	mergeDetect     bool
+	mergeKey        string
 )
 
 var mergeCmd = &cobra.Command{
-	Use:   "merge",
+	Use:   "merge [files or patterns...]",
 	Short: "Merge multiple CSV files into one",
+	Long: `Merge CSV files into one.
+
+Inputs are the *.csv files of --input-dir (with --recursive, of its
+subdirectories too), or files and glob patterns given with --input or as
+arguments. "**" matches any number of directories:
+
+  csvops merge --input 'data/**/2025-*.csv' --output 2025.csv
+
+Directory and pattern matches are merged in natural order, so part_10.csv
+follows part_9.csv. --source-columns appends _source_file and _source_row to
+every row for lineage.
+
+--detect-dialect sniffs each file's delimiter (comma, semicolon, tab or pipe)
+and encoding (UTF-8, UTF-16 with a BOM, Latin-1), so exports from different
+tools can be merged; the output is always comma-separated UTF-8.
+
+--key keeps one row per key value, the one from the latest file in merge
+order, which suits merging daily snapshots where later files correct
+earlier ones.
+
+--mode controls how files with different headers are combined:
+  positional  copy rows as they are, keeping the first file's header (default)
+  strict      fail before writing if any header differs from the first
+  align       reorder each file's columns by name to the first header
+  union       write every column seen in any file, empty where missing`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		patterns := append(splitColumns(mergeInput), args...)
+		if mergeInputDir == "" && len(patterns) == 0 {
+			return fmt.Errorf("provide --input-dir, --input or file arguments")
+		}
+
 		outFile, err := os.Create(mergeOutput)
 		if err != nil {
 			return fmt.Errorf("create output: %w", err)
@@ -29,13 +68,19 @@ var mergeCmd = &cobra.Command{
 
 		var bar *progressbar.ProgressBar
 		res, err := csvops.Merge(context.Background(), csvops.MergeOptions{
-			InputDir:   mergeInputDir,
-			Output:     outFile,
-			WithHeader: mergeWithHeader,
-			Delimiter:  ',',
-			SkipErrors: true,
+			InputDir:      mergeInputDir,
+			Recursive:     mergeRecursive,
+			Patterns:      patterns,
+			Output:        outFile,
+			WithHeader:    mergeWithHeader,
+			Mode:          csvops.MergeMode(mergeMode),
+			SourceColumns: mergeSource,
+			DetectDialect: mergeDetect,
+			KeyColumns:    splitColumns(mergeKey),
+			Delimiter:     ',',
+			SkipErrors:    true,
 			OnWarn: func(path string, e error) {
-				fmt.Printf("⚠️  Skipping %s: %v\n", filepath.Base(path), e)
+				warnf("⚠️  Skipping %s: %v\n", filepath.Base(path), e)
 			},
 			Progress: func(done, total int64) {
 				if bar == nil {
@@ -48,20 +93,87 @@ var mergeCmd = &cobra.Command{
 			return err
 		}
 
-		if res.FilesProcessed == 0 {
-			fmt.Println("⚠️  No CSV files found to merge.")
-			return nil
+		headers := []string{"path", "rows", "superseded", "delimiter", "encoding", "added", "missing", "repeated", "reordered"}
+		rows := make([][]string, len(res.Files))
+		changed := 0
+		for i, f := range res.Files {
+			rows[i] = []string{f.Path, strconv.FormatInt(f.Rows, 10), strconv.FormatInt(f.Superseded, 10), f.Delimiter, f.Encoding,
+				strings.Join(f.Added, "|"), strings.Join(f.Missing, "|"), strings.Join(f.Repeated, "|"), strconv.FormatBool(f.Reordered)}
+			if f.Changed() {
+				changed++
+			}
 		}
-		fmt.Printf("\n✅ Merged %d CSV files into %s (%d rows)\n", res.FilesProcessed, mergeOutput, res.RowsWritten)
-		return nil
+		return emitTable(os.Stdout, res, headers, rows, func() error {
+			if res.FilesProcessed == 0 {
+				fmt.Println("⚠️  No CSV files found to merge.")
+				return nil
+			}
+			if changed > 0 {
+				fmt.Printf("\n🧩 %d of %d files have different columns:\n", changed, len(res.Files))
+				table := tablewriter.NewWriter(os.Stdout)
+				table.SetHeader([]string{"File", "Rows", "Added", "Missing", "Repeated", "Reordered"})
+				table.SetAutoWrapText(false)
+				table.SetAlignment(tablewriter.ALIGN_LEFT)
+				for _, f := range res.Files {
+					if !f.Changed() {
+						continue
+					}
+					reordered := ""
+					if f.Reordered {
+						reordered = "yes"
+					}
+					table.Append([]string{filepath.Base(f.Path), strconv.FormatInt(f.Rows, 10), strings.Join(f.Added, ", "), strings.Join(f.Missing, ", "), strings.Join(f.Repeated, ", "), reordered})
+				}
+				table.Render()
+				if mergeMode == string(csvops.MergePositional) {
+					fmt.Println("⚠️  Rows were copied by position; use --mode align or --mode union to match columns by name.")
+				}
+			}
+			if mergeDetect {
+				printDialects(res.Files)
+			}
+			if res.Duplicates > 0 {
+				fmt.Printf("\n🔁 Dropped %d rows superseded by a later row with the same key.\n", res.Duplicates)
+			}
+			fmt.Printf("\n✅ Merged %d CSV files into %s (%d rows)\n", res.FilesProcessed, mergeOutput, res.RowsWritten)
+			return nil
+		})
 	},
 }
 
+// printDialects lists the files whose dialect differs from plain
+// comma-separated UTF-8.
+func printDialects(files []csvops.MergeFileReport) {
+	var odd []csvops.MergeFileReport
+	for _, f := range files {
+		if f.Delimiter != "," || f.Encoding != csvops.EncodingUTF8 {
+			odd = append(odd, f)
+		}
+	}
+	if len(odd) == 0 {
+		return
+	}
+	fmt.Printf("\n🔤 %d files were converted:\n", len(odd))
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"File", "Delimiter", "Encoding"})
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	for _, f := range odd {
+		table.Append([]string{filepath.Base(f.Path), strconv.Quote(f.Delimiter), f.Encoding})
+	}
+	table.Render()
+}
+
 func init() {
 	rootCmd.AddCommand(mergeCmd)
 
 	mergeCmd.Flags().StringVar(&mergeInputDir, "input-dir", "", "Directory containing CSV files to merge")
+	mergeCmd.Flags().BoolVar(&mergeRecursive, "recursive", false, "Also merge CSV files in subdirectories of --input-dir")
+	mergeCmd.Flags().StringVar(&mergeInput, "input", "", "Comma-separated files or glob patterns (e.g. 'data/**/2025-*.csv')")
 	mergeCmd.Flags().StringVar(&mergeOutput, "output", "merged.csv", "Path for the output CSV file")
 	mergeCmd.Flags().BoolVar(&mergeWithHeader, "with-header", true, "Include headers from the first file")
-	_ = mergeCmd.MarkFlagRequired("input-dir")
+	mergeCmd.Flags().StringVar(&mergeMode, "mode", string(csvops.MergePositional), "Column handling: positional, strict, align or union")
+	mergeCmd.Flags().BoolVar(&mergeSource, "source-columns", false, "Append _source_file and _source_row columns to every row")
+	mergeCmd.Flags().BoolVar(&mergeDetect, "detect-dialect", false, "Detect each file's delimiter and encoding")
+	mergeCmd.Flags().StringVar(&mergeKey, "key", "", "Comma-separated key columns; keep only the latest file's row per key")
 }
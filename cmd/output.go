@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Values accepted by the global --format flag.
+const (
+	formatTable    = "table"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+)
+
+var outputFormat string
+
+func validateFormat() error {
+	switch outputFormat {
+	case formatTable, formatJSON, formatYAML, formatCSV, formatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("unknown --format %q (want table, json, yaml, csv or markdown)", outputFormat)
+}
+
+// machineOutput reports whether --format asks for something other than the
+// human-readable tables and summary lines.
+func machineOutput() bool {
+	return outputFormat != formatTable
+}
+
+// emitResult writes res to w in the chosen --format. For the default table
+// format it calls human instead. csv and markdown render the scalar fields of
+// res as a single row. Commands that stream CSV data to stdout pass
+// os.Stderr as w so the two never mix.
+func emitResult(w io.Writer, res any, human func() error) error {
+	headers, row := flattenFields(res)
+	return emitTable(w, res, headers, [][]string{row}, human)
+}
+
+// emitTable is emitResult for results with a natural tabular form: headers
+// and rows are used for csv and markdown, res for json and yaml.
+func emitTable(w io.Writer, res any, headers []string, rows [][]string, human func() error) error {
+	switch outputFormat {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case formatYAML:
+		return writeYAML(w, res)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(headers); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case formatMarkdown:
+		return writeMarkdown(w, headers, rows)
+	}
+	return human()
+}
+
+// writeYAML goes through JSON so the json struct tags define the field names
+// for both formats. JSON is valid YAML; clearing the node styles turns the
+// flow-style document into ordinary block YAML while keeping field order.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var plain func(n *yaml.Node)
+	plain = func(n *yaml.Node) {
+		n.Style = 0
+		for _, c := range n.Content {
+			plain(c)
+		}
+	}
+	plain(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func writeMarkdown(w io.Writer, headers []string, rows [][]string) error {
+	var b bytes.Buffer
+	line := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			c = strings.ReplaceAll(c, "|", `\|`)
+			c = strings.ReplaceAll(strings.ReplaceAll(c, "\r\n", "<br>"), "\n", "<br>")
+			b.WriteString(" " + c + " |")
+		}
+		b.WriteString("\n")
+	}
+	line(headers)
+	b.WriteString("|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, r := range rows {
+		line(r)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// flattenFields returns the JSON names and formatted values of the scalar
+// fields of a struct, skipping slices, maps and nested structs.
+func flattenFields(v any) ([]string, []string) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return []string{"value"}, []string{fmt.Sprint(v)}
+	}
+	var headers, row []string
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Struct, reflect.Pointer, reflect.Interface:
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		headers = append(headers, name)
+		row = append(row, fmt.Sprint(rv.Field(i).Interface()))
+	}
+	return headers, row
+}
+
+// resultWriter is where a command that can stream CSV to stdout writes its
+// --format result: stdout when the data went to a file, stderr otherwise.
+func resultWriter(dataPath string) io.Writer {
+	if dataPath == "" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// warnf prints a warning to stdout with the table format and to stderr
+// otherwise, keeping machine-readable output parseable.
+func warnf(format string, args ...any) {
+	w := io.Writer(os.Stdout)
+	if machineOutput() {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
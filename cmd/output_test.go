@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFlattenFields(t *testing.T) {
+	res := struct {
+		TotalRows int64    `json:"total_rows"`
+		Skipped   bool     `json:"skipped"`
+		Files     []string `json:"files"`
+		Internal  string   `json:"-"`
+		Plain     string
+	}{TotalRows: 7, Skipped: true, Files: []string{"a"}, Plain: "x"}
+
+	headers, row := flattenFields(res)
+	if got := strings.Join(headers, ","); got != "total_rows,skipped,Plain" {
+		t.Errorf("headers = %s", got)
+	}
+	if got := strings.Join(row, ","); got != "7,true,x" {
+		t.Errorf("row = %s", got)
+	}
+}
+
+func TestWriteMarkdownEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, []string{"a", "b"}, [][]string{{"x|y", "line1\nline2"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "| a | b |\n| --- | --- |\n| x\\|y | line1<br>line2 |\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteYAMLUsesJSONNames(t *testing.T) {
+	var buf bytes.Buffer
+	v := struct {
+		TotalRows int64  `json:"total_rows"`
+		Value     string `json:"value"`
+	}{3, "true"}
+	if err := writeYAML(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	// "true" must stay a string, so it has to be quoted.
+	want := "total_rows: 3\nvalue: \"true\"\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pivotInput      string
+	pivotOutput     string
+	pivotRows       string
+	pivotColumn     string
+	pivotValue      string
+	pivotAgg        string
+	pivotFill       string
+	pivotMaxColumns int
+	pivotDelimiter  string
+)
+
+var pivotCmd = &cobra.Command{
+	Use:   "pivot",
+	Short: "Reshape long data to wide (one column per value)",
+	Long: `Reshape long data to wide: one output row per distinct --rows value, one
+column per distinct --column value (in order of first appearance), with
+cells taken from --value.
+
+  csvops pivot --input sales_long.csv --rows store --column month --value sales
+
+Values that land in the same cell are combined with --agg: first (default),
+last, count, sum, min, max or mean. The file is read twice: once to find the
+output columns, once to fill them. When the input is grouped by the row
+columns, rows are written as each group ends; otherwise they are held in
+memory until the end.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(pivotDelimiter)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if pivotOutput != "" {
+			f, err := os.Create(pivotOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.Pivot(context.Background(), csvops.PivotOptions{
+			Input:       pivotInput,
+			Output:      out,
+			RowKeys:     splitColumns(pivotRows),
+			PivotColumn: pivotColumn,
+			ValueColumn: pivotValue,
+			Agg:         csvops.PivotAgg(pivotAgg),
+			Fill:        pivotFill,
+			MaxColumns:  pivotMaxColumns,
+			Delimiter:   delim,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Pivoting")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		w := resultWriter(pivotOutput)
+		return emitResult(w, res, func() error {
+			fmt.Fprintf(w, "\n✅ Pivoted %d rows into %d rows × %d columns.\n", res.TotalRows, res.RowsWritten, len(res.Columns))
+			if res.Collisions > 0 {
+				fmt.Fprintf(w, "ℹ️  %d values shared a cell and were combined with %s.\n", res.Collisions, pivotAgg)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pivotCmd)
+
+	pivotCmd.Flags().StringVar(&pivotInput, "input", "", "Input CSV file path (required)")
+	pivotCmd.Flags().StringVar(&pivotOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	pivotCmd.Flags().StringVar(&pivotRows, "rows", "", "Comma-separated columns identifying an output row (required)")
+	pivotCmd.Flags().StringVar(&pivotColumn, "column", "", "Column whose values become output columns (required)")
+	pivotCmd.Flags().StringVar(&pivotValue, "value", "", "Column holding the cell values (required)")
+	pivotCmd.Flags().StringVar(&pivotAgg, "agg", string(csvops.AggFirst), "How to combine values sharing a cell: first, last, count, sum, min, max or mean")
+	pivotCmd.Flags().StringVar(&pivotFill, "fill", "", "Value for cells with no data")
+	pivotCmd.Flags().IntVar(&pivotMaxColumns, "max-columns", 1000, "Fail if --column has more distinct values than this")
+	pivotCmd.Flags().StringVar(&pivotDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = pivotCmd.MarkFlagRequired("input")
+	_ = pivotCmd.MarkFlagRequired("rows")
+	_ = pivotCmd.MarkFlagRequired("column")
+	_ = pivotCmd.MarkFlagRequired("value")
+}
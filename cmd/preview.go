@@ -35,30 +35,43 @@ var previewCmd = &cobra.Command{
 		}
 
 		for _, e := range res.SkipErrors {
-			fmt.Printf("⚠️  Skipping row due to error: %v\n", e)
+			warnf("⚠️  Skipping row due to error: %v\n", e)
 		}
 
-		if len(res.Rows) == 0 {
-			fmt.Println("⚠️  No data rows found")
-			return nil
+		headers := res.Headers
+		if len(headers) == 0 {
+			// --no-header: number the columns so csv/markdown stay rectangular.
+			width := 0
+			for _, row := range res.Rows {
+				width = max(width, len(row))
+			}
+			for i := 1; i <= width; i++ {
+				headers = append(headers, fmt.Sprintf("column_%d", i))
+			}
 		}
+		return emitTable(os.Stdout, res, headers, res.Rows, func() error {
+			if len(res.Rows) == 0 {
+				fmt.Println("⚠️  No data rows found")
+				return nil
+			}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetAutoWrapText(false)
-		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
-		table.SetRowLine(true)
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetAutoWrapText(false)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetRowLine(true)
 
-		if len(res.Headers) > 0 {
-			table.SetHeader(res.Headers)
-		}
-		for _, row := range res.Rows {
-			table.Append(row)
-		}
-		table.Render()
+			if len(res.Headers) > 0 {
+				table.SetHeader(res.Headers)
+			}
+			for _, row := range res.Rows {
+				table.Append(row)
+			}
+			table.Render()
 
-		fmt.Printf("\n📄 Showing %d row(s) from '%s'\n", len(res.Rows), previewInput)
-		return nil
+			fmt.Printf("\n📄 Showing %d row(s) from '%s'\n", len(res.Rows), previewInput)
+			return nil
+		})
 	},
 }
 
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileInput     string
+	profileOutput    string
+	profileJSON      string
+	profileMaxUnique int
+	profileTop       int
+	profileBins      int
+	profileDelimiter string
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Write an HTML (and optionally JSON) data profile report for a CSV file",
+	Long: `Profile a CSV file in one pass and write a self-contained HTML report.
+
+The report covers inferred column types, null ratios, distinct counts,
+numeric distributions, top values, value patterns (e.g. AAA-9999) and the
+Pearson correlation between numeric columns. --json also writes the same
+data as JSON.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(profileDelimiter)
+		if err != nil {
+			return err
+		}
+		if profileOutput == "" {
+			profileOutput = strings.TrimSuffix(profileInput, filepath.Ext(profileInput)) + "_profile.html"
+		}
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.Profile(context.Background(), csvops.ProfileOptions{
+			Input:     profileInput,
+			Delimiter: delim,
+			MaxUnique: profileMaxUnique,
+			TopN:      profileTop,
+			Bins:      profileBins,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Profiling")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Create(profileOutput)
+		if err != nil {
+			return fmt.Errorf("create report: %w", err)
+		}
+		if err := csvops.WriteProfileHTML(f, res); err != nil {
+			f.Close()
+			return fmt.Errorf("write report: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+
+		if profileJSON != "" {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(profileJSON, append(data, '\n'), 0o644); err != nil {
+				return fmt.Errorf("write json: %w", err)
+			}
+		}
+
+		return emitResult(os.Stdout, res, func() error {
+			fmt.Printf("\n✅ Profiled %d rows × %d columns. Report written to %s\n", res.Rows, len(res.Columns), profileOutput)
+			if profileJSON != "" {
+				fmt.Printf("📄 JSON written to %s\n", profileJSON)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+
+	profileCmd.Flags().StringVar(&profileInput, "input", "", "Input CSV file path (required)")
+	profileCmd.Flags().StringVar(&profileOutput, "output", "", "HTML report path (default <input>_profile.html)")
+	profileCmd.Flags().StringVar(&profileJSON, "json", "", "Also write the profile as JSON to this path")
+	profileCmd.Flags().IntVar(&profileMaxUnique, "max-unique", 100000, "Max distinct values counted exactly per column before switching to estimates (0 = unlimited)")
+	profileCmd.Flags().IntVar(&profileTop, "top", 5, "Number of top values and patterns per column")
+	profileCmd.Flags().IntVar(&profileBins, "bins", csvops.DefaultHistogramBins, "Number of histogram bins")
+	profileCmd.Flags().StringVar(&profileDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = profileCmd.MarkFlagRequired("input")
+}
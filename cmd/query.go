@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/olekukonko/tablewriter"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryTables    []string
+	queryOutput    string
+	queryCache     bool
+	queryCacheDir  string
+	queryOnDisk    bool
+	queryInferRows int
+	queryDelimiter string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   `query "<SQL>"`,
+	Short: "Run SQL over CSV files",
+	Long: `Load CSV files into SQLite tables and run a SQL query over them. Column
+types (INTEGER, REAL or TEXT) are inferred from the first --infer-rows rows,
+so numeric comparisons and aggregates work as expected.
+
+Each --table is name=path, or just a path to name the table after the file
+(orders.csv becomes orders).
+
+Results go to stdout in the --format of your choice: a table by default,
+csv and json stream row by row. --output writes them as CSV to a file
+instead.
+
+--cache keeps each loaded CSV in a SQLite file under your user cache
+directory (or --cache-dir) and reuses it until the CSV changes, so repeated
+queries against a large file skip the load.
+
+  csvops query "SELECT country, count(*) FROM users GROUP BY 1" --table users.csv
+  csvops query "SELECT u.email, sum(o.total) FROM u JOIN o ON o.user_id = u.id GROUP BY 1" \
+    --table u=users.csv --table o=orders.csv --format csv --cache`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(queryDelimiter)
+		if err != nil {
+			return err
+		}
+		if len(queryTables) == 0 {
+			return fmt.Errorf("please provide at least one --table")
+		}
+		var tables []csvops.QueryTable
+		for _, t := range queryTables {
+			name, path, ok := strings.Cut(t, "=")
+			if !ok {
+				name, path = "", t
+			}
+			tables = append(tables, csvops.QueryTable{Name: strings.TrimSpace(name), Path: strings.TrimSpace(path)})
+		}
+
+		cacheDir := queryCacheDir
+		if queryCache && cacheDir == "" {
+			dir, err := os.UserCacheDir()
+			if err != nil {
+				return fmt.Errorf("find cache dir: %w", err)
+			}
+			cacheDir = filepath.Join(dir, "csvops", "query")
+		}
+
+		format := outputFormat
+		out := io.Writer(os.Stdout)
+		if queryOutput != "" {
+			f, err := os.Create(queryOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out, format = f, formatCSV
+		}
+		sink := newQuerySink(out, format)
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.Query(context.Background(), csvops.QueryOptions{
+			SQL:       args[0],
+			Tables:    tables,
+			CacheDir:  cacheDir,
+			OnDisk:    queryOnDisk,
+			InferRows: queryInferRows,
+			Delimiter: delim,
+			OnColumns: sink.start,
+			OnRow:     sink.row,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Loading")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if err := sink.finish(); err != nil {
+			return err
+		}
+
+		if queryOutput != "" {
+			return emitResult(os.Stdout, res, func() error {
+				fmt.Printf("\n✅ Wrote %d rows to %s\n", res.Rows, queryOutput)
+				return nil
+			})
+		}
+		if !machineOutput() {
+			fmt.Fprintf(os.Stderr, "\n📄 %d row(s)\n", res.Rows)
+		}
+		return nil
+	},
+}
+
+// querySink writes query results in one of the --format values. csv and json
+// stream each row as it arrives; table, markdown and yaml need every row
+// first, so they are collected and written by finish.
+type querySink struct {
+	w       io.Writer
+	format  string
+	columns []string
+	csv     *csv.Writer
+	n       int
+	rows    [][]string        // table and markdown
+	objects []json.RawMessage // yaml
+}
+
+func newQuerySink(w io.Writer, format string) *querySink {
+	return &querySink{w: w, format: format}
+}
+
+func (s *querySink) start(columns []string) error {
+	s.columns = columns
+	switch s.format {
+	case formatCSV:
+		s.csv = csv.NewWriter(s.w)
+		return s.csv.Write(columns)
+	case formatJSON:
+		_, err := io.WriteString(s.w, "[")
+		return err
+	}
+	return nil
+}
+
+func (s *querySink) row(values []any) error {
+	s.n++
+	switch s.format {
+	case formatCSV:
+		return s.csv.Write(queryTexts(values))
+	case formatJSON:
+		obj, err := s.object(values)
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if s.n == 1 {
+			sep = "\n  "
+		}
+		_, err = io.WriteString(s.w, sep+string(obj))
+		return err
+	case formatYAML:
+		obj, err := s.object(values)
+		s.objects = append(s.objects, obj)
+		return err
+	}
+	s.rows = append(s.rows, queryTexts(values))
+	return nil
+}
+
+func (s *querySink) finish() error {
+	switch s.format {
+	case formatCSV:
+		s.csv.Flush()
+		return s.csv.Error()
+	case formatJSON:
+		end := "\n]\n"
+		if s.n == 0 {
+			end = "]\n"
+		}
+		_, err := io.WriteString(s.w, end)
+		return err
+	case formatYAML:
+		if s.objects == nil {
+			s.objects = []json.RawMessage{}
+		}
+		return writeYAML(s.w, s.objects)
+	case formatMarkdown:
+		return writeMarkdown(s.w, s.columns, s.rows)
+	}
+	table := tablewriter.NewWriter(s.w)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader(s.columns)
+	table.AppendBulk(s.rows)
+	table.Render()
+	return nil
+}
+
+// object encodes a row as a JSON object with keys in column order, keeping
+// numbers as numbers and NULL as null.
+func (s *querySink) object(values []any) (json.RawMessage, error) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if raw, ok := v.([]byte); ok {
+			v = string(raw)
+		}
+		k, _ := json.Marshal(s.columns[i])
+		val, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteString(": ")
+		b.Write(val)
+	}
+	b.WriteString("}")
+	return json.RawMessage(b.String()), nil
+}
+
+// queryTexts formats result values as CSV fields; NULL becomes empty.
+func queryTexts(values []any) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case []byte:
+			out[i] = string(v)
+		case float64:
+			out[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			out[i] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringArrayVar(&queryTables, "table", nil, "Table to load as name=path, or a path to name it after the file (repeatable)")
+	queryCmd.Flags().StringVar(&queryOutput, "output", "", "Write the results as CSV to this file (optional, default is stdout in --format)")
+	queryCmd.Flags().BoolVar(&queryCache, "cache", false, "Reuse loaded tables from the user cache directory until the CSV changes")
+	queryCmd.Flags().StringVar(&queryCacheDir, "cache-dir", "", "Cache directory (implies --cache)")
+	queryCmd.Flags().BoolVar(&queryOnDisk, "on-disk", false, "Load uncached tables into a temporary database file instead of memory")
+	queryCmd.Flags().IntVar(&queryInferRows, "infer-rows", 10_000, "Rows sampled to infer column types")
+	queryCmd.Flags().StringVar(&queryDelimiter, "delimiter", ",", "CSV delimiter character")
+}
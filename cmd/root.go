@@ -33,11 +33,27 @@ Supports operations like:
   • stats      - get descriptive statistics
   • preview    - preview first N rows
   • to-sqlite  - convert to SQLite
+  • from-sqlite - export SQLite tables or queries to CSV
+  • sample     - random or stratified row samples
+  • slice      - head, tail and row ranges
+  • index      - row offset index for fast random access
+  • profile    - HTML/JSON data profile report
+  • validate   - check a CSV against a JSON Table Schema
+  • lint       - find and fix malformed CSV structure
+  • diff       - compare two CSV files by key
+  • setop      - union, intersect and except across files
+  • pivot      - reshape long data to wide
+  • unpivot    - reshape wide data to long
+  • transpose  - flip rows and columns, or explode one record
+  • query      - run SQL over CSV files
 ... and more coming soon!`,
 
 	Version:       version,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFormat()
+	},
 }
 
 func Execute() {
@@ -48,6 +64,5 @@ func Execute() {
 }
 
 func init() {
-	// Optionally define global flags here
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Optional config file (default is $HOME/.csvops.yaml)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", formatTable, "Result format: table, json, yaml, csv or markdown")
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sampleInput      string
+	sampleOutput     string
+	sampleFraction   float64
+	sampleSize       int
+	sampleBy         string
+	sampleSeed       int64
+	sampleWithHeader bool
+	sampleDelimiter  string
+)
+
+var sampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Take a random sample of rows from a CSV file",
+	Long: `Take a random sample of rows from a CSV file.
+
+Use --fraction to keep each row with a fixed probability (e.g. 0.01 for ~1%),
+or --size to keep exactly N rows chosen uniformly in a single pass.
+With --by, the fraction or size applies to each distinct value of that column.
+Pass --seed to make the sample reproducible.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(sampleDelimiter)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if sampleOutput != "" {
+			f, err := os.Create(sampleOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.Sample(context.Background(), csvops.SampleOptions{
+			Input:      sampleInput,
+			Output:     out,
+			Fraction:   sampleFraction,
+			Size:       sampleSize,
+			StratifyBy: sampleBy,
+			Seed:       sampleSeed,
+			WithHeader: sampleWithHeader,
+			Delimiter:  delim,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Sampling")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return emitResult(resultWriter(sampleOutput), res, func() error {
+			if len(res.Strata) > 0 {
+				fmt.Fprintln(os.Stderr)
+				for _, s := range res.Strata {
+					fmt.Fprintf(os.Stderr, "  %s: %d of %d\n", s.Value, s.Sampled, s.Rows)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "\n✅ Sampled %d rows out of %d (seed %d).\n", res.Sampled, res.TotalRows, res.Seed)
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sampleCmd)
+
+	sampleCmd.Flags().StringVar(&sampleInput, "input", "", "Input CSV file path (required)")
+	sampleCmd.Flags().StringVar(&sampleOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	sampleCmd.Flags().Float64Var(&sampleFraction, "fraction", 0, "Keep each row with this probability (0-1)")
+	sampleCmd.Flags().IntVar(&sampleSize, "size", 0, "Keep exactly N random rows")
+	sampleCmd.Flags().StringVar(&sampleBy, "by", "", "Column to stratify by (fraction/size applies per value)")
+	sampleCmd.Flags().Int64Var(&sampleSeed, "seed", 0, "Random seed for a reproducible sample (default random)")
+	sampleCmd.Flags().BoolVar(&sampleWithHeader, "with-header", true, "Include header in output")
+	sampleCmd.Flags().StringVar(&sampleDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = sampleCmd.MarkFlagRequired("input")
+	sampleCmd.MarkFlagsOneRequired("fraction", "size")
+	sampleCmd.MarkFlagsMutuallyExclusive("fraction", "size")
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setopInput         string
+	setopOutput        string
+	setopKey           string
+	setopCaseSensitive bool
+	setopMemoryRows    int
+	setopDelimiter     string
+)
+
+var setopCmd = &cobra.Command{
+	Use:   "setop <union|intersect|except> [files...]",
+	Short: "Union, intersect or subtract CSV files by key or whole row",
+	Long: `Combine two or more CSV files as sets of rows:
+
+  union      one row per distinct key across all files, the first one seen
+  intersect  rows of the first file whose key is in every other file
+  except     rows of the first file whose key is in none of the other files
+
+Rows are compared on --key columns (case-insensitive unless
+--case-sensitive), or on every column of the first file when --key is
+omitted. The output has the first file's header; other files' columns are
+matched to it by name, and a column a file lacks counts as empty.
+
+When the files together have more than --memory-rows rows, each is sorted by
+key on disk and the output comes out in key order rather than input order.
+
+  csvops setop except --input crm.csv,billing.csv --key email --output missing.csv
+  csvops setop intersect a.csv b.csv c.csv --key id`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(setopDelimiter)
+		if err != nil {
+			return err
+		}
+		inputs := append(splitColumns(setopInput), args[1:]...)
+
+		out := os.Stdout
+		if setopOutput != "" {
+			f, err := os.Create(setopOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.SetOp(context.Background(), csvops.SetOpOptions{
+			Op:            csvops.SetOperation(args[0]),
+			Inputs:        inputs,
+			KeyColumns:    splitColumns(setopKey),
+			CaseSensitive: setopCaseSensitive,
+			Output:        out,
+			MemoryRows:    setopMemoryRows,
+			Delimiter:     delim,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Comparing")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		w := resultWriter(setopOutput)
+		headers := []string{"path", "rows"}
+		rows := make([][]string, len(res.Files))
+		for i, f := range res.Files {
+			rows[i] = []string{f.Path, strconv.FormatInt(f.Rows, 10)}
+		}
+		return emitTable(w, res, headers, rows, func() error {
+			fmt.Fprintf(w, "\n✅ %s of %d files: %d rows written", res.Op, len(res.Files), res.RowsWritten)
+			if setopOutput != "" {
+				fmt.Fprintf(w, " to %s", setopOutput)
+			}
+			fmt.Fprintln(w, ".")
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setopCmd)
+
+	setopCmd.Flags().StringVar(&setopInput, "input", "", "Comma-separated input CSV files (files can also be given as arguments)")
+	setopCmd.Flags().StringVar(&setopOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	setopCmd.Flags().StringVar(&setopKey, "key", "", "Comma-separated key columns (default: the whole row)")
+	setopCmd.Flags().BoolVar(&setopCaseSensitive, "case-sensitive", false, "Compare keys case-sensitively")
+	setopCmd.Flags().IntVar(&setopMemoryRows, "memory-rows", 1_000_000, "Rows held in memory before switching to an on-disk sort-merge")
+	setopCmd.Flags().StringVar(&setopDelimiter, "delimiter", ",", "CSV delimiter character")
+}
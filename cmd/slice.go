@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sliceInput     string
+	sliceOutput    string
+	sliceHead      int
+	sliceTail      int
+	sliceFrom      int
+	sliceTo        int
+	sliceEvery     int
+	sliceNoHeader  bool
+	sliceDelimiter string
+)
+
+var sliceCmd = &cobra.Command{
+	Use:   "slice",
+	Short: "Extract the head, tail or a row range of a CSV file",
+	Long: `Extract the head, tail or a row range of a CSV file as valid CSV.
+
+Rows are numbered from 1, starting after the header. --tail reads backward
+from the end of the file, so it is fast even on very large files.
+--every N keeps every Nth row of the selection.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(sliceDelimiter)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if sliceOutput != "" {
+			f, err := os.Create(sliceOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		res, err := csvops.Slice(context.Background(), csvops.SliceOptions{
+			Input:     sliceInput,
+			Output:    out,
+			Head:      sliceHead,
+			Tail:      sliceTail,
+			From:      sliceFrom,
+			To:        sliceTo,
+			Every:     sliceEvery,
+			NoHeader:  sliceNoHeader,
+			Delimiter: delim,
+		})
+		if err != nil {
+			return err
+		}
+
+		return emitResult(resultWriter(sliceOutput), res, func() error {
+			if sliceOutput != "" {
+				fmt.Printf("✅ Wrote %d row(s) to %s\n", res.RowsWritten, sliceOutput)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sliceCmd)
+
+	sliceCmd.Flags().StringVar(&sliceInput, "input", "", "Input CSV file path (required)")
+	sliceCmd.Flags().StringVar(&sliceOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	sliceCmd.Flags().IntVar(&sliceHead, "head", 0, "Keep the first N rows")
+	sliceCmd.Flags().IntVar(&sliceTail, "tail", 0, "Keep the last N rows")
+	sliceCmd.Flags().IntVar(&sliceFrom, "from", 0, "First row to keep (1-based, inclusive)")
+	sliceCmd.Flags().IntVar(&sliceTo, "to", 0, "Last row to keep (1-based, inclusive; default end of file)")
+	sliceCmd.Flags().IntVar(&sliceEvery, "every", 0, "Keep every Nth row of the selection")
+	sliceCmd.Flags().BoolVar(&sliceNoHeader, "no-header", false, "Do not treat first row as header")
+	sliceCmd.Flags().StringVar(&sliceDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = sliceCmd.MarkFlagRequired("input")
+	sliceCmd.MarkFlagsMutuallyExclusive("head", "tail", "from")
+	sliceCmd.MarkFlagsMutuallyExclusive("head", "tail", "to")
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
 	"github.com/schollz/progressbar/v3"
@@ -10,11 +12,19 @@ import (
 )
 
 var (
-	inputPath   string
-	outputDir   string
-	rowsPerFile int
-	withHeader  bool
-	delimiter   string
+	inputPath    string
+	outputDir    string
+	rowsPerFile  int
+	withHeader   bool
+	delimiter    string
+	splitBy      string
+	splitHive    bool
+	splitMaxOpen int
+	splitBytes   string
+	splitParts   int
+	splitName    string
+	splitExists  string
+	splitMani    bool
 )
 
 var splitCmd = &cobra.Command{
@@ -30,25 +40,72 @@ var splitCmd = &cobra.Command{
 		}
 
 		var bar *progressbar.ProgressBar
-		res, err := csvops.Split(context.Background(), csvops.SplitOptions{
-			Input:       inputPath,
-			OutputDir:   outputDir,
-			RowsPerFile: rowsPerFile,
-			WithHeader:  withHeader,
-			Delimiter:   delim,
+		opts := csvops.SplitOptions{
+			Input:        inputPath,
+			OutputDir:    outputDir,
+			RowsPerFile:  rowsPerFile,
+			WithHeader:   withHeader,
+			Delimiter:    delim,
+			PartitionBy:  splitColumns(splitBy),
+			Hive:         splitHive,
+			MaxOpenFiles: splitMaxOpen,
+			NameTemplate: splitName,
+			IfExists:     csvops.IfExistsAction(splitExists),
+			Manifest:     splitMani,
 			Progress: func(done, total int64) {
 				if bar == nil {
 					bar = progressbar.Default(total, "Splitting")
 				}
 				_ = bar.Set64(done)
 			},
-		})
+		}
+		// --rows has a default, so it only applies when no other mode is chosen.
+		if splitBytes != "" || splitParts > 0 || len(opts.PartitionBy) > 0 {
+			if cmd.Flags().Changed("rows") {
+				return fmt.Errorf("--rows cannot be combined with --max-bytes, --parts or --by")
+			}
+			opts.RowsPerFile = 0
+		}
+		if len(opts.PartitionBy) > 0 && !withHeader {
+			return fmt.Errorf("--by needs --with-header: partition columns are found by header name")
+		}
+		if splitBytes != "" {
+			n, err := parseByteSize(splitBytes)
+			if err != nil {
+				return err
+			}
+			opts.MaxBytesPerFile = n
+		}
+		opts.NumParts = splitParts
+
+		res, err := csvops.Split(context.Background(), opts)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("\n✅ Finished splitting %d rows into %d file(s).\n", res.RowsProcessed, res.FilesCreated)
-		return nil
+		rows := make([][]string, len(res.Files))
+		for i, f := range res.Files {
+			rows[i] = []string{f.Path, strconv.FormatInt(f.Rows, 10), strconv.FormatInt(f.Bytes, 10), strconv.FormatBool(f.Skipped)}
+		}
+		return emitTable(os.Stdout, res, []string{"path", "rows", "bytes", "skipped"}, rows, func() error {
+			if len(res.Partitions) > 0 {
+				fmt.Println()
+				for _, p := range res.Partitions {
+					fmt.Printf("  %s (%d rows)\n", p.Path, p.Rows)
+				}
+			}
+			skipped := 0
+			for _, f := range res.Files {
+				if f.Skipped {
+					skipped++
+				}
+			}
+			if skipped > 0 {
+				fmt.Printf("\n⚠️  %d existing file(s) skipped.\n", skipped)
+			}
+			fmt.Printf("\n✅ Finished splitting %d rows into %d file(s).\n", res.RowsProcessed, res.FilesCreated)
+			return nil
+		})
 	},
 }
 
@@ -60,4 +117,12 @@ func init() {
 	splitCmd.Flags().IntVar(&rowsPerFile, "rows", 1000, "Max rows per output file")
 	splitCmd.Flags().BoolVar(&withHeader, "with-header", true, "Include header in each output file")
 	splitCmd.Flags().StringVar(&delimiter, "delimiter", ",", "CSV delimiter character")
+	splitCmd.Flags().StringVar(&splitBy, "by", "", "Comma-separated column(s) to partition by instead of splitting by row count")
+	splitCmd.Flags().BoolVar(&splitHive, "hive", false, "Write partitions as col=value/data.csv directories")
+	splitCmd.Flags().StringVar(&splitBytes, "max-bytes", "", "Max size per output file, e.g. 100MB (records are never split)")
+	splitCmd.Flags().IntVar(&splitParts, "parts", 0, "Split into N parts of (nearly) equal row count")
+	splitCmd.Flags().IntVar(&splitMaxOpen, "max-open", 64, "Max partition files held open at once")
+	splitCmd.Flags().StringVar(&splitName, "name", "", "Output file name template: {n}, {n:04}, {stem}, {value}, {ts} (default part_{n}.csv)")
+	splitCmd.Flags().StringVar(&splitExists, "if-exists", "replace", "Action if an output file exists: replace | skip | fail")
+	splitCmd.Flags().BoolVar(&splitMani, "manifest", false, "Write manifest.json with each file's rows, bytes and SHA-256")
 }
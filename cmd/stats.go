@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
 	"github.com/olekukonko/tablewriter"
@@ -15,6 +17,12 @@ import (
 var (
 	statsInput     string
 	statsMaxUnique int
+	statsEstimate  bool
+	statsTop       int
+	statsHistKind  string
+	statsBins      int
+	statsShowHist  bool
+	statsPatterns  bool
 )
 
 var statsCmd = &cobra.Command{
@@ -27,9 +35,14 @@ var statsCmd = &cobra.Command{
 
 		var bar *progressbar.ProgressBar
 		res, err := csvops.Stats(context.Background(), csvops.StatsOptions{
-			Input:     statsInput,
-			MaxUnique: statsMaxUnique,
-			Delimiter: ',',
+			Input:          statsInput,
+			MaxUnique:      statsMaxUnique,
+			EstimateUnique: statsEstimate,
+			TopN:           statsTop,
+			Histogram:      csvops.HistogramKind(statsHistKind),
+			Bins:           statsBins,
+			Patterns:       statsPatterns,
+			Delimiter:      ',',
 			Progress: func(done, total int64) {
 				if bar == nil {
 					bar = progressbar.Default(total, "Analyzing")
@@ -41,39 +54,174 @@ var statsCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Printf("\n📊 Stats for: %s\n", statsInput)
-		fmt.Printf("Total Rows (excluding header): %d\n", res.TotalRows)
-		fmt.Printf("Columns: %d\n\n", len(res.Columns))
+		headers := []string{"column", "unique", "unique_estimated", "empty", "min_length", "max_length", "top",
+			"count", "min", "max", "mean", "std_dev", "sum", "p25", "p50", "p75", "p95", "p99"}
+		rows := make([][]string, len(res.Columns))
+		for i, col := range res.Columns {
+			top := make([]string, len(col.Top))
+			for j, v := range col.Top {
+				top[j] = v.Value
+			}
+			row := []string{
+				col.Name,
+				strconv.Itoa(col.Unique),
+				strconv.FormatBool(col.UniqueEstimated),
+				strconv.Itoa(col.Empty),
+				strconv.Itoa(col.MinLength),
+				strconv.Itoa(col.MaxLength),
+				strings.Join(top, "|"),
+			}
+			if n := col.Numeric; n != nil {
+				row = append(row, strconv.FormatInt(n.Count, 10))
+				for _, v := range []float64{n.Min, n.Max, n.Mean, n.StdDev, n.Sum, n.P25, n.P50, n.P75, n.P95, n.P99} {
+					row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+				}
+			} else {
+				row = append(row, make([]string, 11)...)
+			}
+			rows[i] = row
+		}
+		return emitTable(os.Stdout, res, headers, rows, func() error {
+			printStats(res)
+			return nil
+		})
+	},
+}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Column", "Unique Values", "Empty Fields", "Top 3 Values"})
-		table.SetAutoWrapText(false)
-		table.SetRowLine(true)
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
+// printStats renders the default table output.
+func printStats(res csvops.StatsResult) {
+	fmt.Printf("\n📊 Stats for: %s\n", statsInput)
+	fmt.Printf("Total Rows (excluding header): %d\n", res.TotalRows)
+	fmt.Printf("Columns: %d\n\n", len(res.Columns))
 
-		for _, col := range res.Columns {
-			unique := fmt.Sprintf("%d", col.Unique)
-			if col.UniqueCapped {
-				unique = fmt.Sprintf(">=%d (capped)", col.Unique)
-			}
-			top := make([]string, 0, len(col.Top))
-			for _, v := range col.Top {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Column", "Unique Values", "Empty Fields", "Length", fmt.Sprintf("Top %d Values", statsTop)})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, col := range res.Columns {
+		unique := fmt.Sprintf("%d", col.Unique)
+		if col.UniqueEstimated {
+			unique = fmt.Sprintf("~%d (±%.1f%%)", col.Unique, 100*col.UniqueError)
+		}
+		top := make([]string, 0, len(col.Top))
+		for _, v := range col.Top {
+			if v.Error > 0 {
+				top = append(top, fmt.Sprintf("%s (~%d)", v.Value, v.Count))
+			} else {
 				top = append(top, fmt.Sprintf("%s (%d)", v.Value, v.Count))
 			}
-			table.Append([]string{
-				col.Name,
-				unique,
-				fmt.Sprintf("%d", col.Empty),
-				strings.Join(top, ", "),
-			})
 		}
-		table.Render()
-		return nil
-	},
+		table.Append([]string{
+			col.Name,
+			unique,
+			fmt.Sprintf("%d", col.Empty),
+			lengthRange(col),
+			strings.Join(top, ", "),
+		})
+	}
+	table.Render()
+
+	numeric := tablewriter.NewWriter(os.Stdout)
+	numeric.SetHeader([]string{"Column", "Count", "Min", "Max", "Mean", "Std Dev", "Sum", "P25", "P50", "P75", "P95", "P99"})
+	numeric.SetAutoWrapText(false)
+	numeric.SetAlignment(tablewriter.ALIGN_RIGHT)
+	numericRows := 0
+	for _, col := range res.Columns {
+		n := col.Numeric
+		if n == nil {
+			continue
+		}
+		row := []string{col.Name, fmt.Sprintf("%d", n.Count)}
+		for _, v := range []float64{n.Min, n.Max, n.Mean, n.StdDev, n.Sum, n.P25, n.P50, n.P75, n.P95, n.P99} {
+			row = append(row, strconv.FormatFloat(v, 'g', 6, 64))
+		}
+		numeric.Append(row)
+		numericRows++
+	}
+	if numericRows > 0 {
+		fmt.Println("\nNumeric columns:")
+		numeric.Render()
+	}
+
+	if statsPatterns {
+		patterns := tablewriter.NewWriter(os.Stdout)
+		patterns.SetHeader([]string{"Column", "Top Patterns", "Semantic Types"})
+		patterns.SetAutoWrapText(false)
+		patterns.SetRowLine(true)
+		patterns.SetAlignment(tablewriter.ALIGN_LEFT)
+		for _, col := range res.Columns {
+			patterns.Append([]string{col.Name, formatPatterns(col.Patterns), formatPatterns(col.SemanticTypes)})
+		}
+		fmt.Println("\nPatterns:")
+		patterns.Render()
+	}
+
+	if statsShowHist {
+		for _, col := range res.Columns {
+			fmt.Printf("\n%s\n", col.Name)
+			if col.Numeric != nil {
+				printHistogram("values", col.Numeric.Histogram)
+			}
+			printHistogram("length", col.LengthHistogram)
+			printHistogram("characters", col.ClassHistogram)
+		}
+	}
+}
+
+// lengthRange formats a column's non-empty value lengths as "min-max".
+func lengthRange(col csvops.ColumnStats) string {
+	if col.MaxLength == 0 {
+		return "-"
+	}
+	if col.MinLength == col.MaxLength {
+		return strconv.Itoa(col.MinLength)
+	}
+	return fmt.Sprintf("%d-%d", col.MinLength, col.MaxLength)
+}
+
+// formatPatterns lists patterns one per line as "92.0%  (999) 999-9999".
+func formatPatterns(ps []csvops.PatternCount) string {
+	lines := make([]string, len(ps))
+	for i, p := range ps {
+		lines[i] = fmt.Sprintf("%5.1f%%  %s", 100*p.Ratio, p.Pattern)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// histogramWidth is the length of the longest bar printed by --histograms.
+const histogramWidth = 40
+
+func printHistogram(title string, bins []csvops.HistogramBin) {
+	if len(bins) == 0 {
+		return
+	}
+	var peak int64
+	labelWidth := 0
+	for _, b := range bins {
+		peak = max(peak, b.Count)
+		labelWidth = max(labelWidth, utf8.RuneCountInString(b.Label))
+	}
+	fmt.Printf("  %s:\n", title)
+	for _, b := range bins {
+		bar := 0
+		if peak > 0 {
+			bar = int(b.Count * histogramWidth / peak)
+		}
+		pad := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(b.Label))
+		fmt.Printf("    %s%s │%s %d\n", b.Label, pad, strings.Repeat("█", bar), b.Count)
+	}
 }
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
 	statsCmd.Flags().StringVar(&statsInput, "input", "", "Input CSV file path")
-	statsCmd.Flags().IntVar(&statsMaxUnique, "max-unique", 100000, "Max unique values tracked per column (0 = unlimited)")
+	statsCmd.Flags().IntVar(&statsMaxUnique, "max-unique", 100000, "Max distinct values counted exactly per column before switching to estimates (0 = unlimited)")
+	statsCmd.Flags().IntVar(&statsTop, "top", csvops.DefaultTopN, "Number of most frequent values to show per column")
+	statsCmd.Flags().StringVar(&statsHistKind, "histogram", string(csvops.HistogramEqualWidth), "Numeric histogram bins: equal-width or quantile")
+	statsCmd.Flags().IntVar(&statsBins, "bins", csvops.DefaultHistogramBins, "Number of histogram bins")
+	statsCmd.Flags().BoolVar(&statsShowHist, "histograms", false, "Print value, length and character-class histograms per column")
+	statsCmd.Flags().BoolVar(&statsPatterns, "patterns", false, "Show the most common value shapes (e.g. (999) 999-9999) and semantic types per column")
+	statsCmd.Flags().BoolVar(&statsEstimate, "estimate-unique", false, "Always estimate distinct counts and top values with sketches (bounded memory)")
 }
@@ -3,7 +3,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/maherelgamil/csvops/pkg/csvops"
 	"github.com/schollz/progressbar/v3"
@@ -12,58 +15,149 @@ import (
 
 var (
 	csvToSqliteInput     string
+	csvToSqliteInputDir  string
+	csvToSqliteRecursive bool
 	csvToSqliteOutput    string
 	csvToSqliteTable     string
+	csvToSqliteSource    string
+	csvToSqliteSchema    string
 	csvToSqliteDelimiter string
 	csvToSqliteIfExists  string
 )
 
 var toSqliteCmd = &cobra.Command{
-	Use:   "to-sqlite",
-	Short: "Convert a CSV file into a SQLite database",
+	Use:   "to-sqlite [files or patterns...]",
+	Short: "Convert CSV files into a SQLite database",
+	Long: `Import one or more CSV files into a SQLite database.
+
+Inputs are a file or glob pattern given with --input plus any given as
+arguments, or the *.csv files of --input-dir (with --recursive, of its
+subdirectories too).
+
+With several inputs, each file gets its own table named after it, unless
+--table is given: then every file is appended to that one table, which has
+the union of their columns plus a --source-column recording each row's file.
+A file that fails to import is reported and the others still load, but the
+command exits non-zero.
+
+--schema runs a SQL file before loading, so tables can be created with real
+types, primary and foreign keys, and indexes. Tables it creates are loaded
+by column name; foreign keys are checked once everything is loaded.
+
+  csvops to-sqlite --input users.csv --output app.db
+  csvops to-sqlite --input-dir exports/ --output app.db --schema schema.sql
+  csvops to-sqlite 'logs/**/*.csv' --table logs --output logs.db`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		delim, err := parseDelimiter(csvToSqliteDelimiter)
 		if err != nil {
 			return err
 		}
+		patterns := args
+		if csvToSqliteInput != "" {
+			patterns = append([]string{csvToSqliteInput}, args...)
+		}
+		if csvToSqliteInputDir == "" && len(patterns) == 0 {
+			return fmt.Errorf("provide --input, --input-dir or file arguments")
+		}
 
 		var bar *progressbar.ProgressBar
-		res, err := csvops.ToSQLite(context.Background(), csvops.ToSQLiteOptions{
-			Input:     csvToSqliteInput,
-			DBPath:    csvToSqliteOutput,
-			Table:     csvToSqliteTable,
-			Delimiter: delim,
-			IfExists:  csvops.IfExistsAction(csvToSqliteIfExists),
-			Progress: func(done, total int64) {
-				if bar == nil {
-					bar = progressbar.Default(total, "Converting")
+		progress := func(done, total int64) {
+			if bar == nil {
+				bar = progressbar.Default(total, "Converting")
+			}
+			_ = bar.Set64(done)
+		}
+		dbPath, _ := filepath.Abs(csvToSqliteOutput)
+
+		if csvToSqliteInputDir == "" && csvToSqliteSchema == "" && len(patterns) == 1 && !strings.ContainsAny(patterns[0], "*?[") {
+			res, err := csvops.ToSQLite(context.Background(), csvops.ToSQLiteOptions{
+				Input:     patterns[0],
+				DBPath:    csvToSqliteOutput,
+				Table:     csvToSqliteTable,
+				Delimiter: delim,
+				IfExists:  csvops.IfExistsAction(csvToSqliteIfExists),
+				Progress:  progress,
+			})
+			if err != nil {
+				return err
+			}
+
+			return emitResult(os.Stdout, res, func() error {
+				if res.Skipped {
+					fmt.Printf("⚠️  Table %q already exists, skipped.\n", res.Table)
+					return nil
 				}
-				_ = bar.Set64(done)
+				fmt.Printf("\n✅ Imported %d rows into %s\n", res.RowsImported, dbPath)
+				return nil
+			})
+		}
+
+		res, err := csvops.ToSQLiteMany(context.Background(), csvops.ToSQLiteManyOptions{
+			InputDir:     csvToSqliteInputDir,
+			Recursive:    csvToSqliteRecursive,
+			Patterns:     patterns,
+			DBPath:       csvToSqliteOutput,
+			Table:        csvToSqliteTable,
+			SourceColumn: csvToSqliteSource,
+			IfExists:     csvops.IfExistsAction(csvToSqliteIfExists),
+			SchemaFile:   csvToSqliteSchema,
+			Delimiter:    delim,
+			SkipErrors:   true,
+			OnWarn: func(path string, e error) {
+				warnf("⚠️  Skipping %s: %v\n", filepath.Base(path), e)
 			},
+			Progress: progress,
 		})
 		if err != nil {
 			return err
 		}
 
-		if res.Skipped {
-			fmt.Printf("⚠️  Table %q already exists, skipped.\n", res.Table)
+		headers := []string{"path", "table", "rows_imported", "skipped", "error"}
+		rows := make([][]string, len(res.Files))
+		failed := 0
+		for i, f := range res.Files {
+			rows[i] = []string{f.Path, f.Table, strconv.FormatInt(f.RowsImported, 10), strconv.FormatBool(f.Skipped), f.Error}
+			if f.Error != "" {
+				failed++
+			}
+		}
+		err = emitTable(os.Stdout, res, headers, rows, func() error {
+			fmt.Println()
+			for _, f := range res.Files {
+				switch {
+				case f.Error != "":
+					fmt.Printf("  ❌ %s: %s\n", f.Path, f.Error)
+				case f.Skipped:
+					fmt.Printf("  ⏭️  %s: table %q already exists, skipped\n", f.Path, f.Table)
+				default:
+					fmt.Printf("  %s → %s (%d rows)\n", f.Path, f.Table, f.RowsImported)
+				}
+			}
+			if res.ForeignKeyViolations > 0 {
+				fmt.Printf("\n⚠️  %d rows violate foreign keys (see PRAGMA foreign_key_check).\n", res.ForeignKeyViolations)
+			}
+			fmt.Printf("\n✅ Imported %d rows from %d files into %d table(s) in %s\n", res.RowsImported, len(res.Files)-failed, len(res.Tables), dbPath)
 			return nil
+		})
+		if err == nil && failed > 0 {
+			err = fmt.Errorf("%d of %d files failed to import", failed, len(res.Files))
 		}
-		dbPath, _ := filepath.Abs(csvToSqliteOutput)
-		fmt.Printf("\n✅ Imported %d rows into %s\n", res.RowsImported, dbPath)
-		return nil
+		return err
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(toSqliteCmd)
 
-	toSqliteCmd.Flags().StringVar(&csvToSqliteInput, "input", "", "Input CSV file path (required)")
+	toSqliteCmd.Flags().StringVar(&csvToSqliteInput, "input", "", "Input CSV file or glob pattern (more can be given as arguments)")
+	toSqliteCmd.Flags().StringVar(&csvToSqliteInputDir, "input-dir", "", "Directory of CSV files to import")
+	toSqliteCmd.Flags().BoolVar(&csvToSqliteRecursive, "recursive", false, "Also import CSV files in subdirectories of --input-dir")
 	toSqliteCmd.Flags().StringVar(&csvToSqliteOutput, "output", "", "Output SQLite DB file path (required)")
-	toSqliteCmd.Flags().StringVar(&csvToSqliteTable, "table", "", "Table name to create in SQLite (defaults to filename)")
+	toSqliteCmd.Flags().StringVar(&csvToSqliteTable, "table", "", "Table name to create in SQLite (defaults to filename; with several inputs, one table for all)")
+	toSqliteCmd.Flags().StringVar(&csvToSqliteSource, "source-column", csvops.SourceFileColumn, "Column recording each row's file when several inputs share --table")
+	toSqliteCmd.Flags().StringVar(&csvToSqliteSchema, "schema", "", "SQL file run before loading (CREATE TABLE with keys, CREATE INDEX)")
 	toSqliteCmd.Flags().StringVar(&csvToSqliteDelimiter, "delimiter", ",", "CSV delimiter character")
 	toSqliteCmd.Flags().StringVar(&csvToSqliteIfExists, "if-exists", "replace", "Action if table exists: replace | skip | append | fail")
 
-	_ = toSqliteCmd.MarkFlagRequired("input")
 	_ = toSqliteCmd.MarkFlagRequired("output")
 }
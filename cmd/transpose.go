@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/spf13/cobra"
+)
+
+var (
+	transposeInput      string
+	transposeOutput     string
+	transposeMaxSize    string
+	transposeExplodeRow int64
+	transposeDelimiter  string
+)
+
+var transposeCmd = &cobra.Command{
+	Use:   "transpose",
+	Short: "Flip rows and columns, or show one record vertically",
+	Long: `Flip rows and columns of a small CSV file: the header becomes the first
+column and every row becomes a column. The whole file is held in memory, so
+files over --max-size are refused.
+
+--explode-row N instead prints data row N (1-based) as "column: value"
+lines, which reads far better than preview for files with many columns. It
+streams, so it works on files of any size.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(transposeDelimiter)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("explode-row") {
+			res, err := csvops.ExplodeRow(context.Background(), csvops.ExplodeRowOptions{
+				Input:     transposeInput,
+				Row:       transposeExplodeRow,
+				Delimiter: delim,
+			})
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, len(res.Fields))
+			for i, f := range res.Fields {
+				rows[i] = []string{f.Column, f.Value}
+			}
+			return emitTable(os.Stdout, res, []string{"column", "value"}, rows, func() error {
+				printRecord(os.Stdout, res)
+				return nil
+			})
+		}
+
+		maxBytes, err := parseByteSize(transposeMaxSize)
+		if err != nil {
+			return err
+		}
+		out := os.Stdout
+		if transposeOutput != "" {
+			f, err := os.Create(transposeOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		res, err := csvops.Transpose(context.Background(), csvops.TransposeOptions{
+			Input:     transposeInput,
+			Output:    out,
+			MaxBytes:  maxBytes,
+			Delimiter: delim,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := resultWriter(transposeOutput)
+		return emitResult(w, res, func() error {
+			fmt.Fprintf(w, "\n✅ Transposed %d rows × %d columns into %d rows × %d columns.\n", res.Rows, res.Columns, res.Columns, res.Rows)
+			return nil
+		})
+	},
+}
+
+// printRecord prints one record as aligned "column: value" lines, indenting
+// the continuation lines of multi-line values.
+func printRecord(w io.Writer, res csvops.ExplodeRowResult) {
+	width := 0
+	for _, f := range res.Fields {
+		width = max(width, utf8.RuneCountInString(f.Column))
+	}
+	fmt.Fprintf(w, "\n📄 Row %d of %s\n\n", res.Row, transposeInput)
+	indent := strings.Repeat(" ", width+2)
+	for _, f := range res.Fields {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(f.Column))
+		value := strings.ReplaceAll(f.Value, "\n", "\n"+indent)
+		fmt.Fprintf(w, "%s:%s %s\n", f.Column, pad, value)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(transposeCmd)
+
+	transposeCmd.Flags().StringVar(&transposeInput, "input", "", "Input CSV file path (required)")
+	transposeCmd.Flags().StringVar(&transposeOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	transposeCmd.Flags().StringVar(&transposeMaxSize, "max-size", "16MiB", "Refuse inputs larger than this (e.g. 64MB)")
+	transposeCmd.Flags().Int64Var(&transposeExplodeRow, "explode-row", 0, "Print data row N (1-based) vertically as column: value")
+	transposeCmd.Flags().StringVar(&transposeDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = transposeCmd.MarkFlagRequired("input")
+}
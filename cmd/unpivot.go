@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unpivotInput     string
+	unpivotOutput    string
+	unpivotID        string
+	unpivotColumns   string
+	unpivotNameCol   string
+	unpivotValueCol  string
+	unpivotSkipEmpty bool
+	unpivotDelimiter string
+)
+
+var unpivotCmd = &cobra.Command{
+	Use:     "unpivot",
+	Aliases: []string{"melt"},
+	Short:   "Reshape wide data to long (name/value pairs)",
+	Long: `Reshape wide data to long: every input row becomes one row per value
+column, holding the --id columns, the column's name and its value.
+
+  csvops unpivot --input sales_wide.csv --id store --name month --value sales
+
+--columns picks the columns to unpivot; by default every column not in --id
+is. The file is streamed in constant memory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(unpivotDelimiter)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if unpivotOutput != "" {
+			f, err := os.Create(unpivotOutput)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		var bar *progressbar.ProgressBar
+		res, err := csvops.Unpivot(context.Background(), csvops.UnpivotOptions{
+			Input:        unpivotInput,
+			Output:       out,
+			IDColumns:    splitColumns(unpivotID),
+			ValueColumns: splitColumns(unpivotColumns),
+			NameColumn:   unpivotNameCol,
+			ValueColumn:  unpivotValueCol,
+			SkipEmpty:    unpivotSkipEmpty,
+			Delimiter:    delim,
+			Progress: func(done, total int64) {
+				if bar == nil {
+					bar = progressbar.Default(total, "Unpivoting")
+				}
+				_ = bar.Set64(done)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		w := resultWriter(unpivotOutput)
+		return emitResult(w, res, func() error {
+			fmt.Fprintf(w, "\n✅ Unpivoted %d rows × %d columns into %d rows.\n", res.TotalRows, len(res.Columns), res.RowsWritten)
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unpivotCmd)
+
+	unpivotCmd.Flags().StringVar(&unpivotInput, "input", "", "Input CSV file path (required)")
+	unpivotCmd.Flags().StringVar(&unpivotOutput, "output", "", "Output CSV file path (optional, default is stdout)")
+	unpivotCmd.Flags().StringVar(&unpivotID, "id", "", "Comma-separated columns copied to every output row")
+	unpivotCmd.Flags().StringVar(&unpivotColumns, "columns", "", "Comma-separated columns to unpivot (default: all but --id)")
+	unpivotCmd.Flags().StringVar(&unpivotNameCol, "name", "name", "Name of the output column holding the column names")
+	unpivotCmd.Flags().StringVar(&unpivotValueCol, "value", "value", "Name of the output column holding the values")
+	unpivotCmd.Flags().BoolVar(&unpivotSkipEmpty, "skip-empty", false, "Leave out pairs whose value is empty")
+	unpivotCmd.Flags().StringVar(&unpivotDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = unpivotCmd.MarkFlagRequired("input")
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/maherelgamil/csvops/pkg/csvops"
+	"github.com/olekukonko/tablewriter"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateInput      string
+	validateSchema     string
+	validateValidOut   string
+	validateInvalidOut string
+	validateMaxErrors  int
+	validateDelimiter  string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a CSV file against a JSON Table Schema",
+	Long: `Check a CSV file against a JSON Table Schema (Frictionless compatible).
+
+The schema declares fields with a type (string, integer, number, boolean,
+date, datetime, any) and constraints (required, unique, minimum, maximum,
+minLength, maxLength, pattern, enum), plus an optional primaryKey and
+missingValues. A "rules" list adds cross-column checks such as
+{"left": "end_date", "op": ">=", "right": "start_date"}.
+
+The file is streamed and every violation is reported with its row, column,
+value and rule. The command exits with status 1 when any violation is found.
+--valid-out and --invalid-out split the rows into two files; invalid rows
+get an extra _errors column.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		delim, err := parseDelimiter(validateDelimiter)
+		if err != nil {
+			return err
+		}
+		schema, err := csvops.LoadSchema(validateSchema)
+		if err != nil {
+			return err
+		}
+
+		opts := csvops.ValidateOptions{
+			Input:         validateInput,
+			Schema:        schema,
+			Delimiter:     delim,
+			MaxViolations: validateMaxErrors,
+		}
+		for _, out := range []struct {
+			path string
+			dst  *io.Writer
+		}{{validateValidOut, &opts.ValidOutput}, {validateInvalidOut, &opts.InvalidOutput}} {
+			if out.path == "" {
+				continue
+			}
+			f, err := os.Create(out.path)
+			if err != nil {
+				return fmt.Errorf("create output: %w", err)
+			}
+			defer f.Close()
+			*out.dst = f
+		}
+
+		var bar *progressbar.ProgressBar
+		opts.Progress = func(done, total int64) {
+			if bar == nil {
+				bar = progressbar.Default(total, "Validating")
+			}
+			_ = bar.Set64(done)
+		}
+
+		res, err := csvops.Validate(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+
+		headers := []string{"row", "column", "value", "rule", "message"}
+		rows := make([][]string, len(res.Violations))
+		for i, v := range res.Violations {
+			rows[i] = []string{strconv.FormatInt(v.Row, 10), v.Column, v.Value, v.Rule, v.Message}
+		}
+		if err := emitTable(os.Stdout, res, headers, rows, func() error {
+			printViolations(res)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if !res.Valid {
+			return fmt.Errorf("validation failed: %d violations in %d of %d rows", res.ViolationCount, res.InvalidRows, res.Rows)
+		}
+		return nil
+	},
+}
+
+func printViolations(res csvops.ValidateResult) {
+	if len(res.Violations) > 0 {
+		fmt.Printf("\n🚫 Violations in %s:\n", validateInput)
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Row", "Column", "Value", "Rule", "Message"})
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		for _, v := range res.Violations {
+			row := "header"
+			if v.Row > 0 {
+				row = strconv.FormatInt(v.Row, 10)
+			}
+			table.Append([]string{row, v.Column, v.Value, v.Rule, v.Message})
+		}
+		table.Render()
+		if hidden := res.ViolationCount - int64(len(res.Violations)); hidden > 0 {
+			fmt.Printf("… and %d more (raise --max-errors to see them)\n", hidden)
+		}
+	}
+
+	if res.Valid {
+		fmt.Printf("\n✅ %s is valid. %d rows checked.\n", validateInput, res.Rows)
+	} else {
+		fmt.Printf("\n📋 %d rows checked: %d valid, %d invalid, %d violations.\n", res.Rows, res.ValidRows, res.InvalidRows, res.ViolationCount)
+	}
+	if validateValidOut != "" {
+		fmt.Printf("📁 Valid rows written to %s\n", validateValidOut)
+	}
+	if validateInvalidOut != "" {
+		fmt.Printf("📁 Invalid rows written to %s\n", validateInvalidOut)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVar(&validateInput, "input", "", "Input CSV file path (required)")
+	validateCmd.Flags().StringVar(&validateSchema, "schema", "", "JSON Table Schema file (required)")
+	validateCmd.Flags().StringVar(&validateValidOut, "valid-out", "", "Write rows that pass to this CSV file")
+	validateCmd.Flags().StringVar(&validateInvalidOut, "invalid-out", "", "Write rows that fail to this CSV file, with an _errors column")
+	validateCmd.Flags().IntVar(&validateMaxErrors, "max-errors", 1000, "Max violations listed in the report (0 = all); all are still counted")
+	validateCmd.Flags().StringVar(&validateDelimiter, "delimiter", ",", "CSV delimiter character")
+
+	_ = validateCmd.MarkFlagRequired("input")
+	_ = validateCmd.MarkFlagRequired("schema")
+}
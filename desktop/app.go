@@ -2,9 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	goruntime "runtime"
@@ -38,6 +36,19 @@ func (a *App) emitProgress(op string) csvops.Progress {
 	}
 }
 
+// emitPart builds a Progress callback that sends a "progress-part" event
+// with the output file currently being written and the (estimated) number of
+// files, so the frontend can show "part 7 of ~20".
+func (a *App) emitPart(op string) csvops.Progress {
+	return func(part, parts int64) {
+		runtime.EventsEmit(a.ctx, "progress-part", map[string]any{
+			"op":    op,
+			"part":  part,
+			"parts": parts,
+		})
+	}
+}
+
 // ----- File / dir pickers --------------------------------------------------
 
 func (a *App) OpenCSVFile() (string, error) {
@@ -96,13 +107,27 @@ type FileInfo struct {
 }
 
 // FileInfoCSV returns size + row count + headers for a CSV. The frontend uses
-// it to show file context and to populate column dropdowns.
+// it to show file context and to populate column dropdowns. The row count
+// comes from the row index, which is built here on first open so paging
+// afterwards never rescans the file.
 func (a *App) FileInfoCSV(path string) (FileInfo, error) {
 	st, err := os.Stat(path)
 	if err != nil {
 		return FileInfo{}, err
 	}
-	rows, _ := csvops.CountDataRows(path, ',')
+	var rows int64
+	idx, err := csvops.LoadIndex(path)
+	if err != nil {
+		idx, err = csvops.BuildIndex(a.ctx, csvops.IndexOptions{
+			Input:    path,
+			Progress: a.emitProgress("index"),
+		})
+	}
+	if err == nil {
+		rows = idx.Rows
+	} else {
+		rows, _ = csvops.CountDataRows(path, ',')
+	}
 	headers, _ := readHeaders(path)
 	return FileInfo{
 		Path:    path,
@@ -155,53 +180,21 @@ type PagePayload struct {
 // ReadPage returns a paginated window of rows from a CSV. Offset is the index
 // of the first data row to return (0-based, header excluded). Limit caps the
 // returned rows. The frontend uses this to render the unified table view.
+// Rows are located through the .csvidx sidecar, so deep pages load as fast
+// as the first one.
 func (a *App) ReadPage(path string, offset, limit int) (PagePayload, error) {
 	if limit <= 0 {
 		limit = 100
 	}
-	total, err := csvops.CountDataRows(path, ',')
-	if err != nil {
-		return PagePayload{}, err
-	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return PagePayload{}, err
-	}
-	defer f.Close()
-
-	r := csv.NewReader(f)
-	r.FieldsPerRecord = -1
-
-	headers, err := r.Read()
+	res, err := csvops.ReadRange(path, int64(offset), int64(limit))
 	if err != nil {
 		return PagePayload{}, err
 	}
-
-	// Skip `offset` rows.
-	for i := 0; i < offset; i++ {
-		if _, err := r.Read(); err != nil {
-			return PagePayload{Headers: headers, Offset: int64(offset), TotalRows: total}, nil
-		}
-	}
-
-	rows := make([][]string, 0, limit)
-	for len(rows) < limit {
-		rec, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			continue
-		}
-		rows = append(rows, rec)
-	}
-
 	return PagePayload{
-		Headers:   headers,
-		Rows:      rows,
+		Headers:   res.Headers,
+		Rows:      res.Rows,
 		Offset:    int64(offset),
-		TotalRows: total,
+		TotalRows: res.TotalRows,
 	}, nil
 }
 
@@ -227,14 +220,42 @@ func (a *App) PreviewCSV(path string, rows int, noHeader bool) (PreviewPayload,
 type StatsValueCount struct {
 	Value string `json:"value"`
 	Count int    `json:"count"`
+	Error int    `json:"error"`
 }
 
 type StatsColumn struct {
-	Name         string            `json:"name"`
-	Unique       int               `json:"unique"`
-	UniqueCapped bool              `json:"uniqueCapped"`
-	Empty        int               `json:"empty"`
-	Top          []StatsValueCount `json:"top"`
+	Name            string            `json:"name"`
+	Unique          int               `json:"unique"`
+	UniqueCapped    bool              `json:"uniqueCapped"`
+	UniqueEstimated bool              `json:"uniqueEstimated"`
+	UniqueError     float64           `json:"uniqueError"`
+	Empty           int               `json:"empty"`
+	Top             []StatsValueCount `json:"top"`
+	MinLength       int               `json:"minLength"`
+	MaxLength       int               `json:"maxLength"`
+	Numeric         *StatsNumeric     `json:"numeric"`
+	LengthHistogram []StatsBin        `json:"lengthHistogram"`
+	ClassHistogram  []StatsBin        `json:"classHistogram"`
+}
+
+type StatsBin struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type StatsNumeric struct {
+	Count     int64      `json:"count"`
+	Min       float64    `json:"min"`
+	Max       float64    `json:"max"`
+	Sum       float64    `json:"sum"`
+	Mean      float64    `json:"mean"`
+	StdDev    float64    `json:"stdDev"`
+	P25       float64    `json:"p25"`
+	P50       float64    `json:"p50"`
+	P75       float64    `json:"p75"`
+	P95       float64    `json:"p95"`
+	P99       float64    `json:"p99"`
+	Histogram []StatsBin `json:"histogram"`
 }
 
 type StatsPayload struct {
@@ -255,19 +276,41 @@ func (a *App) StatsCSV(path string, maxUnique int) (StatsPayload, error) {
 	for i, c := range res.Columns {
 		top := make([]StatsValueCount, len(c.Top))
 		for j, v := range c.Top {
-			top[j] = StatsValueCount{Value: v.Value, Count: v.Count}
+			top[j] = StatsValueCount{Value: v.Value, Count: v.Count, Error: v.Error}
 		}
 		cols[i] = StatsColumn{
-			Name:         c.Name,
-			Unique:       c.Unique,
-			UniqueCapped: c.UniqueCapped,
-			Empty:        c.Empty,
-			Top:          top,
+			Name:            c.Name,
+			Unique:          c.Unique,
+			UniqueCapped:    c.UniqueCapped,
+			UniqueEstimated: c.UniqueEstimated,
+			UniqueError:     c.UniqueError,
+			Empty:           c.Empty,
+			Top:             top,
+			MinLength:       c.MinLength,
+			MaxLength:       c.MaxLength,
+			LengthHistogram: statsBins(c.LengthHistogram),
+			ClassHistogram:  statsBins(c.ClassHistogram),
+		}
+		if n := c.Numeric; n != nil {
+			cols[i].Numeric = &StatsNumeric{
+				Count: n.Count, Min: n.Min, Max: n.Max, Sum: n.Sum,
+				Mean: n.Mean, StdDev: n.StdDev,
+				P25: n.P25, P50: n.P50, P75: n.P75, P95: n.P95, P99: n.P99,
+				Histogram: statsBins(n.Histogram),
+			}
 		}
 	}
 	return StatsPayload{TotalRows: res.TotalRows, Columns: cols}, nil
 }
 
+func statsBins(bins []csvops.HistogramBin) []StatsBin {
+	out := make([]StatsBin, len(bins))
+	for i, b := range bins {
+		out[i] = StatsBin{Label: b.Label, Count: b.Count}
+	}
+	return out
+}
+
 // ----- Filter ---------------------------------------------------------------
 
 type FilterRequest struct {
@@ -342,11 +385,12 @@ type SplitPayload struct {
 
 func (a *App) SplitCSV(req SplitRequest) (SplitPayload, error) {
 	res, err := csvops.Split(a.ctx, csvops.SplitOptions{
-		Input:       req.Input,
-		OutputDir:   req.OutputDir,
-		RowsPerFile: req.RowsPerFile,
-		WithHeader:  req.WithHeader,
-		Progress:    a.emitProgress("split"),
+		Input:        req.Input,
+		OutputDir:    req.OutputDir,
+		RowsPerFile:  req.RowsPerFile,
+		WithHeader:   req.WithHeader,
+		Progress:     a.emitProgress("split"),
+		PartProgress: a.emitPart("split"),
 	})
 	if err != nil {
 		return SplitPayload{}, err
@@ -405,11 +449,14 @@ type MergeRequest struct {
 	InputDir   string `json:"inputDir"`
 	Output     string `json:"output"`
 	WithHeader bool   `json:"withHeader"`
+	Mode       string `json:"mode"`
 }
 
 type MergePayload struct {
 	FilesProcessed int   `json:"filesProcessed"`
 	RowsWritten    int64 `json:"rowsWritten"`
+	// FilesChanged counts files whose columns differ from the output header.
+	FilesChanged int `json:"filesChanged"`
 }
 
 func (a *App) MergeCSV(req MergeRequest) (MergePayload, error) {
@@ -423,15 +470,23 @@ func (a *App) MergeCSV(req MergeRequest) (MergePayload, error) {
 		InputDir:   req.InputDir,
 		Output:     out,
 		WithHeader: req.WithHeader,
+		Mode:       csvops.MergeMode(req.Mode),
 		SkipErrors: true,
 		Progress:   a.emitProgress("merge"),
 	})
 	if err != nil {
 		return MergePayload{}, err
 	}
+	changed := 0
+	for _, f := range res.Files {
+		if f.Changed() {
+			changed++
+		}
+	}
 	return MergePayload{
 		FilesProcessed: res.FilesProcessed,
 		RowsWritten:    res.RowsWritten,
+		FilesChanged:   changed,
 	}, nil
 }
 
@@ -66,6 +66,7 @@ import {
 // ---------- helpers --------------------------------------------------------
 
 type ProgressEvent = { op: string; done: number; total: number };
+type PartEvent = { op: string; part: number; parts: number };
 
 function formatBytes(b: number) {
   if (b < 1024) return `${b} B`;
@@ -102,14 +103,17 @@ export default function App() {
   const [loadingPage, setLoadingPage] = useState(false);
   const [stats, setStats] = useState<main.StatsPayload | null>(null);
   const [progress, setProgress] = useState<ProgressEvent | null>(null);
+  const [part, setPart] = useState<PartEvent | null>(null);
   const [dragging, setDragging] = useState(false);
   const [action, setAction] = useState<ActionKind>(null);
 
   useEffect(() => {
     EventsOn("progress", (p: ProgressEvent) => setProgress(p));
+    EventsOn("progress-part", (p: PartEvent) => setPart(p));
     EventsOn("file-dropped", (path: string) => loadFile(path));
     return () => {
       EventsOff("progress");
+      EventsOff("progress-part");
       EventsOff("file-dropped");
     };
   }, []);
@@ -210,7 +214,7 @@ export default function App() {
       )}
 
       {progress && progress.total > 0 && progress.done < progress.total && (
-        <ProgressBar p={progress} />
+        <ProgressBar p={progress} part={part?.op === progress.op ? part : null} />
       )}
 
       {dragging && <DropOverlay />}
@@ -223,7 +227,7 @@ export default function App() {
           >
             {action === "filter" && <FilterAction info={info} onDone={(out) => loadFile(out)} />}
             {action === "dedupe" && <DedupeAction info={info} onDone={(out) => loadFile(out)} />}
-            {action === "split" && <SplitAction info={info} />}
+            {action === "split" && <SplitAction info={info} onStart={() => setPart(null)} />}
             {action === "sqlite" && <SQLiteAction info={info} />}
             {action === "merge" && <MergeAction />}
           </SheetContent>
@@ -427,12 +431,20 @@ function DropOverlay() {
   );
 }
 
-function ProgressBar({ p }: { p: ProgressEvent }) {
+function ProgressBar({ p, part }: { p: ProgressEvent; part: PartEvent | null }) {
   const pct = p.total > 0 ? Math.round((p.done / p.total) * 100) : 0;
   return (
     <div className="border-t border-border bg-card px-6 py-2.5">
       <div className="mb-1.5 flex items-center justify-between text-xs">
-        <span className="font-medium capitalize text-foreground">{p.op}</span>
+        <span className="font-medium capitalize text-foreground">
+          {p.op}
+          {part && (
+            <span className="ml-2 font-normal normal-case text-muted-foreground">
+              part {part.part.toLocaleString()}
+              {part.parts > 0 && <> of ~{part.parts.toLocaleString()}</>}
+            </span>
+          )}
+        </span>
         <span className="text-muted-foreground">
           {p.done.toLocaleString()} / {p.total.toLocaleString()} ({pct}%)
         </span>
@@ -532,13 +544,39 @@ function ColumnStatsView({ c }: { c: main.StatsColumn }) {
       <div className="grid grid-cols-2 gap-3 text-sm">
         <div>
           <div className="text-xs uppercase tracking-wide text-muted-foreground">Unique</div>
-          <div className="font-semibold">{c.uniqueCapped ? `≥${c.unique}` : c.unique.toLocaleString()}</div>
+          <div className="font-semibold">{c.uniqueEstimated ? `~${c.unique.toLocaleString()} ±${(c.uniqueError * 100).toFixed(1)}%` : c.unique.toLocaleString()}</div>
         </div>
         <div>
           <div className="text-xs uppercase tracking-wide text-muted-foreground">Empty</div>
           <div className="font-semibold">{c.empty.toLocaleString()}</div>
         </div>
+        {c.maxLength > 0 && (
+          <div className="col-span-2">
+            <div className="text-xs uppercase tracking-wide text-muted-foreground">Length</div>
+            <div className="font-semibold">{c.minLength === c.maxLength ? c.minLength : `${c.minLength}–${c.maxLength}`} chars</div>
+          </div>
+        )}
       </div>
+      {c.numeric && (
+        <div>
+          <div className="mb-1 text-xs uppercase tracking-wide text-muted-foreground">Numeric</div>
+          <div className="grid grid-cols-3 gap-x-3 gap-y-1 text-xs">
+            {([
+              ["min", c.numeric.min], ["mean", c.numeric.mean], ["max", c.numeric.max],
+              ["p25", c.numeric.p25], ["median", c.numeric.p50], ["p75", c.numeric.p75],
+              ["p95", c.numeric.p95], ["p99", c.numeric.p99], ["stddev", c.numeric.stdDev],
+            ] as [string, number][]).map(([label, v]) => (
+              <div key={label} className="flex justify-between gap-1">
+                <span className="text-muted-foreground">{label}</span>
+                <span className="font-mono font-semibold">{formatNumber(v)}</span>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+      {c.numeric && <BarChart title="Distribution" bins={c.numeric.histogram} />}
+      {!c.numeric && <BarChart title="Length" bins={c.lengthHistogram} />}
+      {!c.numeric && <BarChart title="Characters" bins={c.classHistogram} />}
       {c.top.length > 0 && (
         <div>
           <div className="mb-1 text-xs uppercase tracking-wide text-muted-foreground">Top values</div>
@@ -546,7 +584,7 @@ function ColumnStatsView({ c }: { c: main.StatsColumn }) {
             {c.top.map((t, i) => (
               <div key={i} className="flex items-center justify-between gap-2 text-xs">
                 <span className="truncate font-mono" title={t.value}>{t.value || <em className="text-muted-foreground">(empty)</em>}</span>
-                <span className="rounded bg-secondary px-2 py-0.5 font-semibold">{t.count.toLocaleString()}</span>
+                <span className="rounded bg-secondary px-2 py-0.5 font-semibold" title={t.error > 0 ? `may be overstated by up to ${t.error.toLocaleString()}` : undefined}>{t.error > 0 ? "~" : ""}{t.count.toLocaleString()}</span>
               </div>
             ))}
           </div>
@@ -556,6 +594,31 @@ function ColumnStatsView({ c }: { c: main.StatsColumn }) {
   );
 }
 
+function BarChart({ title, bins }: { title: string; bins: main.StatsBin[] }) {
+  if (!bins || bins.length < 2) return null;
+  const peak = Math.max(...bins.map((b) => b.count), 1);
+  return (
+    <div>
+      <div className="mb-1 text-xs uppercase tracking-wide text-muted-foreground">{title}</div>
+      <div className="space-y-0.5">
+        {bins.map((b, i) => (
+          <div key={i} className="flex items-center gap-2 text-xs" title={`${b.label}: ${b.count.toLocaleString()}`}>
+            <span className="w-24 shrink-0 truncate text-right font-mono text-muted-foreground">{b.label}</span>
+            <div className="h-3 flex-1 rounded-sm bg-secondary">
+              <div className="h-3 rounded-sm bg-primary" style={{ width: `${(b.count / peak) * 100}%` }} />
+            </div>
+            <span className="w-12 shrink-0 text-right tabular-nums">{b.count.toLocaleString()}</span>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
+
+function formatNumber(v: number): string {
+  return Number.isInteger(v) ? v.toLocaleString() : v.toLocaleString(undefined, { maximumFractionDigits: 3 });
+}
+
 // ---------- shared form widgets ------------------------------------------
 
 function Field({ label, hint, children }: { label: string; hint?: string; children: React.ReactNode }) {
@@ -763,7 +826,7 @@ function DedupeAction({ info, onDone }: { info: main.FileInfo; onDone: (output:
   );
 }
 
-function SplitAction({ info }: { info: main.FileInfo }) {
+function SplitAction({ info, onStart }: { info: main.FileInfo; onStart: () => void }) {
   const [outDir, setOutDir] = useState("");
   const [rowsPerFile, setRowsPerFile] = useState(1000);
   const [withHeader, setWithHeader] = useState(true);
@@ -773,7 +836,7 @@ function SplitAction({ info }: { info: main.FileInfo }) {
   async function pickOutDir() { const p = await OpenDirectory("Select output directory"); if (p) setOutDir(p); }
   async function run() {
     if (!outDir) { setErr("Choose an output directory."); return; }
-    setLoading(true); setErr(""); setResult(null);
+    setLoading(true); setErr(""); setResult(null); onStart();
     try { setResult(await SplitCSV({ input: info.path, outputDir: outDir, rowsPerFile, withHeader } as any)); }
     catch (e: any) { setErr(String(e)); }
     finally { setLoading(false); }
@@ -805,6 +868,7 @@ function MergeAction() {
   const [inDir, setInDir] = useState("");
   const [output, setOutput] = useState("");
   const [withHeader, setWithHeader] = useState(true);
+  const [mode, setMode] = useState("positional");
   const [result, setResult] = useState<main.MergePayload | null>(null);
   const [err, setErr] = useState(""); const [loading, setLoading] = useState(false);
 
@@ -814,7 +878,7 @@ function MergeAction() {
     if (!inDir) { setErr("Choose an input directory."); return; }
     if (!output) { setErr("Choose an output file."); return; }
     setLoading(true); setErr(""); setResult(null);
-    try { setResult(await MergeCSV({ inputDir: inDir, output, withHeader } as any)); }
+    try { setResult(await MergeCSV({ inputDir: inDir, output, withHeader, mode } as any)); }
     catch (e: any) { setErr(String(e)); }
     finally { setLoading(false); }
   }
@@ -827,12 +891,24 @@ function MergeAction() {
         <Checkbox checked={withHeader} onCheckedChange={(v) => setWithHeader(!!v)} />
         Use header from first file
       </label>
+      <Field label="Columns" hint="How to line up files whose headers differ.">
+        <Select value={mode} onValueChange={setMode}>
+          <SelectTrigger><SelectValue /></SelectTrigger>
+          <SelectContent>
+            <SelectItem value="positional">positional — copy rows as they are</SelectItem>
+            <SelectItem value="strict">strict — fail if headers differ</SelectItem>
+            <SelectItem value="align">align — reorder to the first header</SelectItem>
+            <SelectItem value="union">union — all columns from all files</SelectItem>
+          </SelectContent>
+        </Select>
+      </Field>
       <GoButton onClick={run} loading={loading}>Run merge</GoButton>
       {err && <Banner kind="error">{err}</Banner>}
       {result && (
         <Banner kind="success" output={output}>
           Merged <strong>{result.filesProcessed}</strong> file(s),{" "}
           <strong>{result.rowsWritten.toLocaleString()}</strong> row(s).
+          {result.filesChanged > 0 && <> {result.filesChanged} file(s) had different columns.</>}
         </Banner>
       )}
     </div>
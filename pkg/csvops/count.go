@@ -10,6 +10,18 @@ import (
 // CountDataRows counts non-header data rows in a CSV file, treating the first
 // line as a header. Returns 0 for an empty file or a header-only file.
 func CountDataRows(path string, delim rune) (int64, error) {
+	total, err := countRecords(path, delim)
+	if err != nil {
+		return 0, err
+	}
+	if total > 0 {
+		total-- // exclude header
+	}
+	return total, nil
+}
+
+// countRecords counts every parseable record in a CSV file, header included.
+func countRecords(path string, delim rune) (int64, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return 0, fmt.Errorf("open %s: %w", path, err)
@@ -31,8 +43,5 @@ func CountDataRows(path string, delim rune) (int64, error) {
 		}
 		total++
 	}
-	if total > 0 {
-		total-- // exclude header
-	}
 	return total, nil
 }
@@ -1,6 +1,7 @@
 // Package csvops provides reusable CSV operations (split, dedupe, filter, merge,
-// stats, preview, to-sqlite) as a library. Both the csvops CLI and the desktop
-// app depend on this package.
+// setop, pivot, unpivot, transpose, stats, profile, validate, lint, diff,
+// query, preview, to-sqlite, from-sqlite, sample, slice) as a library. Both the
+// csvops CLI and the desktop app depend on this package.
 package csvops
 
 // Progress is an optional callback invoked during long-running operations.
@@ -23,9 +23,9 @@ type DedupeOptions struct {
 
 // DedupeResult is returned from Dedupe.
 type DedupeResult struct {
-	TotalRows  int64
-	UniqueRows int
-	Duplicates int
+	TotalRows  int64 `json:"total_rows"`
+	UniqueRows int   `json:"unique_rows"`
+	Duplicates int   `json:"duplicates"`
 }
 
 // Dedupe removes duplicate rows from a CSV file based on one or more key columns.
@@ -204,6 +204,17 @@ func BuildDedupeKey(row []string, indexes []int, caseSensitive bool) string {
 	return strings.Join(parts, "||")
 }
 
+// rowKey is BuildDedupeKey for rows that may be shorter than the header:
+// missing fields count as empty.
+func rowKey(row []string, idx []int, caseSensitive bool) string {
+	for _, i := range idx {
+		if i >= len(row) {
+			row = append(row[:len(row):len(row)], make([]string, i-len(row)+1)...)
+		}
+	}
+	return BuildDedupeKey(row, idx, caseSensitive)
+}
+
 func resolveKeyIndexes(headers, keys []string, caseSensitive bool) ([]int, error) {
 	out := make([]int, 0, len(keys))
 	for _, key := range keys {
@@ -0,0 +1,253 @@
+package csvops
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings recognized by DetectDialect.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin-1"
+)
+
+// Dialect is the delimiter and text encoding of a CSV file.
+type Dialect struct {
+	Delimiter rune
+	Encoding  string
+}
+
+// sniffBytes is how much of a file DetectDialect reads.
+const sniffBytes = 64 << 10
+
+// delimiterCandidates are tried by DetectDialect, in order of preference.
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// DetectDialect guesses a file's encoding and delimiter from its first 64 KiB.
+// A byte order mark identifies UTF-8 and UTF-16; otherwise the sample is
+// UTF-8 if it decodes as such and Latin-1 if not. The delimiter is the
+// candidate (comma, semicolon, tab, pipe) that splits the sample's records
+// into the most consistent number of fields, defaulting to a comma.
+func DetectDialect(path string) (Dialect, error) {
+	d := Dialect{Delimiter: ',', Encoding: EncodingUTF8}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return d, err
+	}
+	defer f.Close()
+	raw := make([]byte, sniffBytes)
+	n, err := io.ReadFull(f, raw)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return d, err
+	}
+	raw = raw[:n]
+	truncated := n == sniffBytes
+
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		d.Encoding = EncodingUTF8BOM
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}):
+		d.Encoding = EncodingUTF16LE
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		d.Encoding = EncodingUTF16BE
+	default:
+		check := raw
+		if truncated {
+			// Ignore a rune cut off by the end of the sample.
+			for i := 0; i < utf8.UTFMax && len(check) > 0 && !utf8.Valid(check); i++ {
+				check = check[:len(check)-1]
+			}
+		}
+		if !utf8.Valid(check) {
+			d.Encoding = EncodingLatin1
+		}
+	}
+
+	sample, err := io.ReadAll(decodeReader(bytes.NewReader(raw), d.Encoding))
+	if err != nil {
+		return d, err
+	}
+	d.Delimiter = sniffDelimiter(sample, truncated)
+	return d, nil
+}
+
+// sniffDelimiter scores each candidate by the share of records that have as
+// many fields as the first one.
+func sniffDelimiter(sample []byte, truncated bool) rune {
+	best, bestScore, bestWidth := ',', 0.0, 0
+	for _, cand := range delimiterCandidates {
+		r := csv.NewReader(bytes.NewReader(sample))
+		r.Comma = cand
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		var widths []int
+		for len(widths) < 50 {
+			rec, err := r.Read()
+			if err != nil {
+				break
+			}
+			widths = append(widths, len(rec))
+		}
+		if truncated && len(widths) > 1 {
+			widths = widths[:len(widths)-1] // the last record may be cut off
+		}
+		if len(widths) == 0 || widths[0] < 2 {
+			continue
+		}
+		same := 0
+		for _, w := range widths {
+			if w == widths[0] {
+				same++
+			}
+		}
+		score := float64(same) / float64(len(widths))
+		if score > bestScore || (score == bestScore && widths[0] > bestWidth) {
+			best, bestScore, bestWidth = cand, score, widths[0]
+		}
+	}
+	return best
+}
+
+// openDecoded opens path as UTF-8 text, converting from encoding and dropping
+// a byte order mark.
+func openDecoded(path, encoding string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{decodeReader(f, encoding), f}, nil
+}
+
+func decodeReader(r io.Reader, encoding string) io.Reader {
+	br := bufio.NewReader(r)
+	switch encoding {
+	case EncodingUTF8BOM:
+		_, _ = br.Discard(3)
+		return br
+	case EncodingUTF16LE, EncodingUTF16BE:
+		_, _ = br.Discard(2)
+		return &utf16Reader{r: br, bigEndian: encoding == EncodingUTF16BE}
+	case EncodingLatin1:
+		return &latin1Reader{r: br}
+	}
+	return br
+}
+
+// utf16Reader transcodes UTF-16 to UTF-8.
+type utf16Reader struct {
+	r         *bufio.Reader
+	bigEndian bool
+	pending   []byte
+	held      *uint16 // a unit read after a high surrogate that didn't pair
+}
+
+func (u *utf16Reader) unit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("utf-16: odd number of bytes")
+		}
+		return 0, err
+	}
+	if u.bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1]), nil
+	}
+	return uint16(b[1])<<8 | uint16(b[0]), nil
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.pending) > 0 {
+			c := copy(p[n:], u.pending)
+			u.pending = u.pending[c:]
+			n += c
+			continue
+		}
+		var c uint16
+		if u.held != nil {
+			c, u.held = *u.held, nil
+		} else {
+			if n > 0 && u.r.Buffered() < 2 {
+				break // don't block for more input once something is returned
+			}
+			var err error
+			if c, err = u.unit(); err != nil {
+				if n > 0 && err == io.EOF {
+					return n, nil
+				}
+				return n, err
+			}
+		}
+		r := rune(c)
+		switch {
+		case r >= 0xd800 && r < 0xdc00:
+			// A high surrogate needs a low one next; anything else is kept
+			// for the next rune, so a lone surrogate never eats a delimiter.
+			c2, err := u.unit()
+			if err != nil && err != io.EOF {
+				return n, err
+			}
+			r = utf8.RuneError
+			if err == nil {
+				if r2 := rune(c2); r2 >= 0xdc00 && r2 < 0xe000 {
+					r = utf16.DecodeRune(rune(c), r2)
+				} else {
+					u.held = &c2
+				}
+			}
+		case utf16.IsSurrogate(r):
+			r = utf8.RuneError // a lone low surrogate
+		}
+		u.pending = utf8.AppendRune(u.pending[:0], r)
+	}
+	return n, nil
+}
+
+// latin1Reader transcodes ISO-8859-1 to UTF-8.
+type latin1Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(l.pending) > 0 {
+			c := copy(p[n:], l.pending)
+			l.pending = l.pending[c:]
+			n += c
+			continue
+		}
+		if n > 0 && l.r.Buffered() == 0 {
+			break
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		if b < utf8.RuneSelf {
+			p[n] = b
+			n++
+			continue
+		}
+		l.pending = utf8.AppendRune(l.pending[:0], rune(b))
+	}
+	return n, nil
+}
@@ -0,0 +1,48 @@
+package csvops
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectDialect(t *testing.T) {
+	cases := []struct {
+		name, body string
+		want       Dialect
+		text       string // decoded content
+	}{
+		{"comma", "a,b\n1,2\n", Dialect{',', EncodingUTF8}, "a,b\n1,2\n"},
+		{"semicolon", "a;b;c\n1,5;2;3\n4;5;6\n", Dialect{';', EncodingUTF8}, "a;b;c\n1,5;2;3\n4;5;6\n"},
+		{"tab", "a\tb\n\"x,y\"\t2\n", Dialect{'\t', EncodingUTF8}, "a\tb\n\"x,y\"\t2\n"},
+		{"bom", "\xef\xbb\xbfa|b\n1|2\n", Dialect{'|', EncodingUTF8BOM}, "a|b\n1|2\n"},
+		{"utf16le", "\xff\xfea\x00;\x00b\x00\n\x00\xe9\x00;\x00=\xd8\x00\xde\n\x00", Dialect{';', EncodingUTF16LE}, "a;b\né;\U0001f600\n"},
+		{"utf16le lone surrogates", "\xff\xfea\x00,\x00b\x00\n\x00\x00\xd8,\x00x\x00\n\x00\x00\xdc,\x00y\x00\n\x00\x00\xd8", Dialect{',', EncodingUTF16LE}, "a,b\n\ufffd,x\n\ufffd,y\n\ufffd"},
+		{"latin1", "name,city\nJos\xe9,M\xfcnchen\n", Dialect{',', EncodingLatin1}, "name,city\nJosé,München\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "in.csv")
+			writeCSV(t, path, tc.body)
+			d, err := DetectDialect(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d != tc.want {
+				t.Fatalf("dialect = %+v, want %+v", d, tc.want)
+			}
+			f, err := openDecoded(path, d.Encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.text {
+				t.Errorf("decoded = %q, want %q", got, tc.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// Kinds of row change reported by Diff, also written to ChangeColumn.
+const (
+	DiffAdded     = "added"
+	DiffRemoved   = "removed"
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+)
+
+// Diff strategies, reported in DiffResult.Strategy.
+const (
+	DiffHash      = "hash"
+	DiffSortMerge = "sort-merge"
+)
+
+// ChangeColumn is the first column of Diff's unified output.
+const ChangeColumn = "_change"
+
+// DiffOptions configures a Diff operation.
+type DiffOptions struct {
+	// Old and New are the files to compare, e.g. yesterday's and today's
+	// export. Rows are matched by KeyColumns, which must exist in both.
+	Old        string
+	New        string
+	KeyColumns []string
+	// IgnoreColumns are left out of the comparison (e.g. an updated_at
+	// timestamp) but still written to Output.
+	IgnoreColumns []string
+	// Output, when set, receives the unified diff CSV: ChangeColumn followed
+	// by every column of Old, then the columns only New has. Changed cells
+	// read "old → new".
+	Output           io.Writer
+	IncludeUnchanged bool // also write unchanged rows to Output
+	// MaxChanges caps DiffResult.Changes; 0 keeps them all. The counts
+	// always cover every row.
+	MaxChanges int
+	// MemoryRows is how many rows Diff may hold in memory (default
+	// 1,000,000). If the smaller file fits, it is loaded into a hash table and
+	// the larger one streamed past it; otherwise both are sorted by key on
+	// disk and merged.
+	MemoryRows int
+	Delimiter  rune
+	Progress   Progress
+}
+
+// CellChange is one changed value in a changed row.
+type CellChange struct {
+	Column string `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// RowChange describes one added, removed or changed row.
+type RowChange struct {
+	Change string       `json:"change"`
+	Key    []string     `json:"key"`
+	Cells  []CellChange `json:"cells,omitempty"` // changed rows only
+}
+
+// DiffResult is returned from Diff.
+type DiffResult struct {
+	Strategy  string `json:"strategy"`
+	OldRows   int64  `json:"old_rows"`
+	NewRows   int64  `json:"new_rows"`
+	Added     int64  `json:"added"`
+	Removed   int64  `json:"removed"`
+	Changed   int64  `json:"changed"`
+	Unchanged int64  `json:"unchanged"`
+	// DuplicateKeys counts rows whose key was already seen in the same
+	// file. Keys are expected to be unique; rows repeating a key are paired
+	// in file order, the nth old row with the nth new one, and only the
+	// repeats one file has more of are reported as added or removed. With
+	// the hash strategy, repeats in the larger file of keys the smaller file
+	// lacks are not counted.
+	DuplicateKeys  int64    `json:"duplicate_keys"`
+	AddedColumns   []string `json:"added_columns,omitempty"`
+	RemovedColumns []string `json:"removed_columns,omitempty"`
+	// ChangedCells counts changed values per column.
+	ChangedCells map[string]int64 `json:"changed_cells,omitempty"`
+	Changes      []RowChange      `json:"changes,omitempty"`
+	Identical    bool             `json:"identical"`
+}
+
+// Diff compares two CSV files by key and reports added, removed and changed
+// rows with their changed cells. Memory is bounded by MemoryRows whatever
+// the file sizes. Rows are reported in the larger file's order followed by
+// the smaller file's unmatched rows, or in key order with sort-merge.
+func Diff(ctx context.Context, opts DiffOptions) (DiffResult, error) {
+	var res DiffResult
+
+	if opts.Old == "" || opts.New == "" {
+		return res, fmt.Errorf("old and new inputs are required")
+	}
+	if len(opts.KeyColumns) == 0 {
+		return res, fmt.Errorf("at least one key column is required")
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.MemoryRows <= 0 {
+		opts.MemoryRows = 1_000_000
+	}
+
+	var err error
+	if res.OldRows, err = CountDataRows(opts.Old, opts.Delimiter); err != nil {
+		return res, err
+	}
+	if res.NewRows, err = CountDataRows(opts.New, opts.Delimiter); err != nil {
+		return res, err
+	}
+
+	oldSide, err := openDiffSide(opts.Old, opts.Delimiter, opts.KeyColumns)
+	if err != nil {
+		return res, err
+	}
+	defer oldSide.f.Close()
+	newSide, err := openDiffSide(opts.New, opts.Delimiter, opts.KeyColumns)
+	if err != nil {
+		return res, err
+	}
+	defer newSide.f.Close()
+
+	d := newDiffer(opts, &res, oldSide, newSide)
+	if d.w != nil {
+		if err := d.w.Write(append([]string{ChangeColumn}, d.cols...)); err != nil {
+			return res, fmt.Errorf("write header: %w", err)
+		}
+	}
+
+	if min(res.OldRows, res.NewRows) <= int64(opts.MemoryRows) {
+		res.Strategy = DiffHash
+		err = d.hashJoin(ctx)
+	} else {
+		res.Strategy = DiffSortMerge
+		err = d.sortMergeJoin(ctx)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if d.w != nil {
+		d.w.Flush()
+		if err := d.w.Error(); err != nil {
+			return res, fmt.Errorf("writer: %w", err)
+		}
+	}
+	res.Identical = res.Added == 0 && res.Removed == 0 && res.Changed == 0 &&
+		len(res.AddedColumns) == 0 && len(res.RemovedColumns) == 0
+	return res, nil
+}
+
+// diffSide is one open input with its header and key column positions.
+type diffSide struct {
+	path   string
+	f      *os.File
+	r      *csv.Reader
+	header []string
+	keyIdx []int
+}
+
+func openDiffSide(path string, delim rune, keys []string) (*diffSide, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	r := csv.NewReader(f)
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: read headers: %w", path, err)
+	}
+	idx, err := resolveKeyIndexes(header, keys, true)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &diffSide{path: path, f: f, r: r, header: header, keyIdx: idx}, nil
+}
+
+// read returns the next data row, or nil at the end of the file.
+func (s *diffSide) read() ([]string, error) {
+	row, err := s.r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return row, nil
+}
+
+func (s *diffSide) key(row []string) string {
+	return rowKey(row, s.keyIdx, true)
+}
+
+// differ classifies matched and unmatched rows and writes them out.
+type differ struct {
+	opts     DiffOptions
+	res      *DiffResult
+	old, new *diffSide
+	cols     []string // output columns: Old's header, then New-only columns
+	oldAt    []int    // per output column, its index in an Old row or -1
+	newAt    []int
+	compared []int // output columns compared between matched rows
+	w        *csv.Writer
+	out      []string
+	done     int64
+}
+
+func newDiffer(opts DiffOptions, res *DiffResult, old, new *diffSide) *differ {
+	d := &differ{opts: opts, res: res, old: old, new: new}
+	d.cols = append(slices.Clone(old.header), notIn(new.header, old.header)...)
+	res.AddedColumns = notIn(new.header, old.header)
+	res.RemovedColumns = notIn(old.header, new.header)
+	for i, c := range d.cols {
+		d.oldAt = append(d.oldAt, slices.Index(old.header, c))
+		d.newAt = append(d.newAt, slices.Index(new.header, c))
+		if d.oldAt[i] >= 0 && d.newAt[i] >= 0 && !slices.Contains(old.keyIdx, d.oldAt[i]) &&
+			!slices.Contains(opts.IgnoreColumns, c) {
+			d.compared = append(d.compared, i)
+		}
+	}
+	if opts.Output != nil {
+		d.w = csv.NewWriter(opts.Output)
+		d.w.Comma = opts.Delimiter
+		d.out = make([]string, len(d.cols)+1)
+	}
+	return d
+}
+
+func (d *differ) progress() {
+	d.done++
+	safeProgress(d.opts.Progress, d.done, d.res.OldRows+d.res.NewRows)
+}
+
+func (d *differ) record(rc RowChange) {
+	if d.opts.MaxChanges <= 0 || len(d.res.Changes) < d.opts.MaxChanges {
+		d.res.Changes = append(d.res.Changes, rc)
+	}
+}
+
+func (d *differ) keyValues(row []string, s *diffSide) []string {
+	key := make([]string, len(s.keyIdx))
+	for i, j := range s.keyIdx {
+		key[i] = cell(row, j)
+	}
+	return key
+}
+
+// write emits one unified diff row; value gives output column i's cell.
+func (d *differ) write(change string, value func(i int) string) error {
+	if d.w == nil {
+		return nil
+	}
+	d.out[0] = change
+	for i := range d.cols {
+		d.out[i+1] = value(i)
+	}
+	return d.w.Write(d.out)
+}
+
+func (d *differ) added(row []string) error {
+	d.res.Added++
+	d.record(RowChange{Change: DiffAdded, Key: d.keyValues(row, d.new)})
+	return d.write(DiffAdded, func(i int) string { return cell(row, d.newAt[i]) })
+}
+
+func (d *differ) removed(row []string) error {
+	d.res.Removed++
+	d.record(RowChange{Change: DiffRemoved, Key: d.keyValues(row, d.old)})
+	return d.write(DiffRemoved, func(i int) string { return cell(row, d.oldAt[i]) })
+}
+
+func (d *differ) matched(oldRow, newRow []string) error {
+	var cells []CellChange
+	for _, i := range d.compared {
+		o, n := cell(oldRow, d.oldAt[i]), cell(newRow, d.newAt[i])
+		if o != n {
+			cells = append(cells, CellChange{Column: d.cols[i], Old: o, New: n})
+		}
+	}
+	if len(cells) == 0 {
+		d.res.Unchanged++
+		if !d.opts.IncludeUnchanged {
+			return nil
+		}
+		return d.write(DiffUnchanged, func(i int) string {
+			if d.newAt[i] >= 0 {
+				return cell(newRow, d.newAt[i])
+			}
+			return cell(oldRow, d.oldAt[i])
+		})
+	}
+
+	d.res.Changed++
+	if d.res.ChangedCells == nil {
+		d.res.ChangedCells = make(map[string]int64)
+	}
+	for _, c := range cells {
+		d.res.ChangedCells[c.Column]++
+	}
+	d.record(RowChange{Change: DiffChanged, Key: d.keyValues(newRow, d.new), Cells: cells})
+	return d.write(DiffChanged, func(i int) string {
+		switch {
+		case d.newAt[i] < 0:
+			return cell(oldRow, d.oldAt[i])
+		case d.oldAt[i] < 0:
+			return cell(newRow, d.newAt[i])
+		}
+		o, n := cell(oldRow, d.oldAt[i]), cell(newRow, d.newAt[i])
+		if o != n && slices.Contains(d.compared, i) {
+			return o + " → " + n
+		}
+		return n
+	})
+}
+
+// hashEntry is a row of the smaller file, held in memory.
+type hashEntry struct {
+	row     []string
+	matched bool
+}
+
+// hashKey holds the smaller file's rows for one key, in file order, and how
+// many rows of the larger file have had that key so far.
+type hashKey struct {
+	rows []*hashEntry
+	seen int
+}
+
+// hashJoin loads the smaller file into a map and streams the larger one.
+// Rows repeating a key are paired in file order, the nth of one file with
+// the nth of the other, as sortMergeJoin does.
+func (d *differ) hashJoin(ctx context.Context) error {
+	small, large := d.old, d.new
+	if d.res.NewRows < d.res.OldRows {
+		small, large = d.new, d.old
+	}
+	// Which of added/removed an unmatched row is depends on its side.
+	unmatched := func(s *diffSide, row []string) error {
+		if s == d.new {
+			return d.added(row)
+		}
+		return d.removed(row)
+	}
+	pair := func(smallRow, largeRow []string) error {
+		if small == d.old {
+			return d.matched(smallRow, largeRow)
+		}
+		return d.matched(largeRow, smallRow)
+	}
+
+	var entries []*hashEntry
+	byKey := make(map[string]*hashKey)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := small.read()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		d.progress()
+		e := &hashEntry{row: row}
+		entries = append(entries, e)
+		k := small.key(row)
+		hk := byKey[k]
+		if hk == nil {
+			hk = &hashKey{}
+			byKey[k] = hk
+		} else {
+			d.res.DuplicateKeys++
+		}
+		hk.rows = append(hk.rows, e)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := large.read()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		d.progress()
+		var e *hashEntry
+		if hk := byKey[large.key(row)]; hk != nil {
+			if hk.seen > 0 {
+				d.res.DuplicateKeys++
+			}
+			if hk.seen < len(hk.rows) {
+				e = hk.rows[hk.seen]
+			}
+			hk.seen++
+		}
+		if e == nil {
+			if err := unmatched(large, row); err != nil {
+				return err
+			}
+			continue
+		}
+		e.matched = true
+		if err := pair(e.row, row); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if !e.matched {
+			if err := unmatched(small, e.row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortMergeJoin sorts both files by key on disk and walks them together.
+func (d *differ) sortMergeJoin(ctx context.Context) error {
+	runRows := max(d.opts.MemoryRows/2, 1) // both files are sorted before merging
+	oldRows, err := sortByKey(ctx, d.old.r, d.old.key, runRows, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.old.path, err)
+	}
+	defer oldRows.close()
+	newRows, err := sortByKey(ctx, d.new.r, d.new.key, runRows, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.new.path, err)
+	}
+	defer newRows.close()
+
+	// next reads from a sorted stream, counting repeated keys.
+	next := func(s *sortedRows, last *string, started *bool) (keyedRow, bool, error) {
+		kr, ok, err := s.next()
+		if err != nil || !ok {
+			return kr, ok, err
+		}
+		d.progress()
+		if *started && kr.key == *last {
+			d.res.DuplicateKeys++
+		}
+		*last, *started = kr.key, true
+		return kr, true, nil
+	}
+	var lastOld, lastNew string
+	var startedOld, startedNew bool
+
+	o, oOK, err := next(oldRows, &lastOld, &startedOld)
+	if err != nil {
+		return err
+	}
+	n, nOK, err := next(newRows, &lastNew, &startedNew)
+	if err != nil {
+		return err
+	}
+	for oOK || nOK {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch {
+		case !nOK || (oOK && o.key < n.key):
+			if err := d.removed(o.row); err != nil {
+				return err
+			}
+			o, oOK, err = next(oldRows, &lastOld, &startedOld)
+		case !oOK || n.key < o.key:
+			if err := d.added(n.row); err != nil {
+				return err
+			}
+			n, nOK, err = next(newRows, &lastNew, &startedNew)
+		default:
+			if err := d.matched(o.row, n.row); err != nil {
+				return err
+			}
+			if o, oOK, err = next(oldRows, &lastOld, &startedOld); err != nil {
+				return err
+			}
+			n, nOK, err = next(newRows, &lastNew, &startedNew)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cell returns row[i], or "" when i is -1 or past the end of a short row.
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
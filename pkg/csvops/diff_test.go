@@ -0,0 +1,154 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeDiffInputs(t *testing.T) (oldPath, newPath string) {
+	dir := t.TempDir()
+	oldPath = filepath.Join(dir, "old.csv")
+	newPath = filepath.Join(dir, "new.csv")
+	writeCSV(t, oldPath, "id,name,city,seen\n1,Ann,Cairo,mon\n2,Bob,Giza,mon\n3,Cy,Luxor,mon\n4,Di,Aswan,mon\n")
+	writeCSV(t, newPath, "id,name,city,seen,email\n2,Bob,Alex,tue,b@x\n4,Di,Aswan,tue,d@x\n5,Ed,Suez,tue,e@x\n1,Ann,Cairo,tue,a@x\n")
+	return oldPath, newPath
+}
+
+func TestDiff_Strategies(t *testing.T) {
+	oldPath, newPath := writeDiffInputs(t)
+	for _, tc := range []struct {
+		memoryRows int
+		strategy   string
+	}{
+		{0, DiffHash},
+		{2, DiffSortMerge},
+	} {
+		t.Run(tc.strategy, func(t *testing.T) {
+			var buf bytes.Buffer
+			res, err := Diff(context.Background(), DiffOptions{
+				Old:           oldPath,
+				New:           newPath,
+				KeyColumns:    []string{"id"},
+				IgnoreColumns: []string{"seen"},
+				Output:        &buf,
+				MemoryRows:    tc.memoryRows,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Strategy != tc.strategy {
+				t.Fatalf("strategy = %s", res.Strategy)
+			}
+			if res.Added != 1 || res.Removed != 1 || res.Changed != 1 || res.Unchanged != 2 || res.Identical {
+				t.Errorf("res = %+v", res)
+			}
+			if strings.Join(res.AddedColumns, ",") != "email" || len(res.RemovedColumns) != 0 {
+				t.Errorf("columns: added %v, removed %v", res.AddedColumns, res.RemovedColumns)
+			}
+			if res.ChangedCells["city"] != 1 || len(res.ChangedCells) != 1 {
+				t.Errorf("ChangedCells = %v", res.ChangedCells)
+			}
+
+			var changes []string
+			for _, c := range res.Changes {
+				changes = append(changes, fmt.Sprintf("%s %v %v", c.Change, c.Key, c.Cells))
+			}
+			sort.Strings(changes)
+			want := "added [5] []|changed [2] [{city Giza Alex}]|removed [3] []"
+			if strings.Join(changes, "|") != want {
+				t.Errorf("changes = %q", changes)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if lines[0] != "_change,id,name,city,seen,email" {
+				t.Errorf("header = %s", lines[0])
+			}
+			sort.Strings(lines[1:])
+			wantRows := []string{
+				"added,5,Ed,Suez,tue,e@x",
+				"changed,2,Bob,Giza → Alex,tue,b@x",
+				"removed,3,Cy,Luxor,mon,",
+			}
+			if strings.Join(lines[1:], "\n") != strings.Join(wantRows, "\n") {
+				t.Errorf("output:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestDiff_IdenticalAndDuplicateKeys(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	writeCSV(t, a, "k,v\nx,1\ny,2\n")
+	writeCSV(t, b, "k,v\ny,2\nx,1\n")
+
+	res, err := Diff(context.Background(), DiffOptions{Old: a, New: b, KeyColumns: []string{"k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Identical || res.Unchanged != 2 {
+		t.Errorf("res = %+v", res)
+	}
+
+	writeCSV(t, b, "k,v\ny,2\nx,1\nx,1\n")
+	for _, mem := range []int{0, 1} {
+		res, err = Diff(context.Background(), DiffOptions{Old: a, New: b, KeyColumns: []string{"k"}, MemoryRows: mem})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Identical || res.DuplicateKeys != 1 || res.Added != 1 {
+			t.Errorf("%s: res = %+v", res.Strategy, res)
+		}
+	}
+
+	// The same repeated key in both files pairs up in order.
+	writeCSV(t, a, "k,v\nx,1\nx,2\ny,3\n")
+	writeCSV(t, b, "k,v\nx,1\nx,2\ny,3\n")
+	var strategies []string
+	for _, mem := range []int{0, 1} {
+		res, err = Diff(context.Background(), DiffOptions{Old: a, New: b, KeyColumns: []string{"k"}, MemoryRows: mem})
+		if err != nil {
+			t.Fatal(err)
+		}
+		strategies = append(strategies, res.Strategy)
+		if !res.Identical || res.Unchanged != 3 || res.Added != 0 || res.Removed != 0 || res.DuplicateKeys != 2 {
+			t.Errorf("%s: res = %+v", res.Strategy, res)
+		}
+	}
+	if strategies[0] == strategies[1] {
+		t.Errorf("strategies = %v, want both", strategies)
+	}
+}
+
+func TestSortByKey_SpillsAndKeepsInputOrder(t *testing.T) {
+	in := "b,1\na,1\nc,1\na,2\nb,2\na,3\n"
+	for _, runRows := range []int{1, 2, 100} {
+		r := csv.NewReader(strings.NewReader(in))
+		s, err := sortByKey(context.Background(), r, func(row []string) string { return row[0] }, runRows, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for {
+			kr, ok, err := s.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			got = append(got, strings.Join(kr.row, ""))
+		}
+		s.close()
+		if strings.Join(got, " ") != "a1 a2 a3 b1 b2 c1" {
+			t.Errorf("runRows %d: %v", runRows, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package csvops
+
+import (
+	"container/heap"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// keyedRow is a data row with the key it is sorted by.
+type keyedRow struct {
+	key string
+	row []string
+}
+
+// sortedRows yields rows in key order, rows with equal keys in input order.
+// Inputs that fit in one run are sorted in memory; larger ones are spilled
+// to temporary files in sorted runs of runRows rows and merged back.
+type sortedRows struct {
+	mem  []keyedRow
+	runs runHeap
+	all  []*runReader // every run, for close
+	dir  string
+}
+
+// sortByKey reads every row of r and sorts it by key, holding at most
+// runRows rows in memory. onRow is called for each row read. The caller must
+// close the result.
+func sortByKey(ctx context.Context, r *csv.Reader, key func([]string) string, runRows int, onRow func()) (*sortedRows, error) {
+	s := &sortedRows{}
+	var buf []keyedRow
+	spill := func() error {
+		sort.SliceStable(buf, func(i, j int) bool { return buf[i].key < buf[j].key })
+		if s.dir == "" {
+			dir, err := os.MkdirTemp("", "csvops-sort-*")
+			if err != nil {
+				return fmt.Errorf("create temp dir: %w", err)
+			}
+			s.dir = dir
+		}
+		path := filepath.Join(s.dir, fmt.Sprintf("run_%d.csv", len(s.runs)))
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create sort run: %w", err)
+		}
+		w := csv.NewWriter(f)
+		for _, kr := range buf {
+			if err := w.Write(append([]string{kr.key}, kr.row...)); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return err
+		}
+		rr := csv.NewReader(f)
+		rr.FieldsPerRecord = -1
+		run := &runReader{f: f, r: rr, seq: len(s.all)}
+		s.all = append(s.all, run)
+		s.runs = append(s.runs, run)
+		buf = buf[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			s.close()
+			return nil, err
+		}
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		if onRow != nil {
+			onRow()
+		}
+		buf = append(buf, keyedRow{key(row), row})
+		if len(buf) >= runRows {
+			if err := spill(); err != nil {
+				s.close()
+				return nil, err
+			}
+		}
+	}
+
+	if len(s.runs) == 0 {
+		sort.SliceStable(buf, func(i, j int) bool { return buf[i].key < buf[j].key })
+		s.mem = buf
+		return s, nil
+	}
+	if len(buf) > 0 {
+		if err := spill(); err != nil {
+			s.close()
+			return nil, err
+		}
+	}
+	for _, rr := range s.runs {
+		if err := rr.advance(); err != nil {
+			s.close()
+			return nil, err
+		}
+	}
+	live := s.runs[:0]
+	for _, rr := range s.runs {
+		if rr.ok {
+			live = append(live, rr)
+		}
+	}
+	s.runs = live
+	heap.Init(&s.runs)
+	return s, nil
+}
+
+// next returns the next row in key order; ok is false at the end.
+func (s *sortedRows) next() (kr keyedRow, ok bool, err error) {
+	if s.dir == "" {
+		if len(s.mem) == 0 {
+			return kr, false, nil
+		}
+		kr, s.mem = s.mem[0], s.mem[1:]
+		return kr, true, nil
+	}
+	if len(s.runs) == 0 {
+		return kr, false, nil
+	}
+	top := s.runs[0]
+	kr = top.cur
+	if err := top.advance(); err != nil {
+		return kr, false, err
+	}
+	if top.ok {
+		heap.Fix(&s.runs, 0)
+	} else {
+		heap.Pop(&s.runs)
+	}
+	return kr, true, nil
+}
+
+// close removes the temporary run files.
+func (s *sortedRows) close() {
+	for _, rr := range s.all {
+		rr.f.Close()
+	}
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// runReader is one sorted run on disk, positioned at its current row.
+type runReader struct {
+	f   *os.File
+	r   *csv.Reader
+	seq int // run order, so equal keys keep input order
+	cur keyedRow
+	ok  bool
+}
+
+func (rr *runReader) advance() error {
+	rec, err := rr.r.Read()
+	if err == io.EOF {
+		rr.ok = false
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read sort run: %w", err)
+	}
+	rr.cur, rr.ok = keyedRow{rec[0], rec[1:]}, true
+	return nil
+}
+
+// runHeap orders runs by their current key.
+type runHeap []*runReader
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].cur.key != h[j].cur.key {
+		return h[i].cur.key < h[j].cur.key
+	}
+	return h[i].seq < h[j].seq
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -31,8 +31,8 @@ type FilterOptions struct {
 
 // FilterResult is returned from Filter.
 type FilterResult struct {
-	TotalRows int64
-	Matched   int64
+	TotalRows int64 `json:"total_rows"`
+	Matched   int64 `json:"matched"`
 }
 
 // Filter streams rows from the input CSV to opts.Output, keeping only rows
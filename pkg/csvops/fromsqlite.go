@@ -0,0 +1,265 @@
+package csvops
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlobEncoding controls how FromSQLite writes BLOB values.
+type BlobEncoding string
+
+const (
+	BlobHex    BlobEncoding = "hex"
+	BlobBase64 BlobEncoding = "base64"
+)
+
+// FromSQLiteOptions configures a FromSQLite operation. Exactly one of Table,
+// Query and OutputDir selects what is exported.
+type FromSQLiteOptions struct {
+	DBPath string
+	// Table exports a whole table to Output.
+	Table string
+	// Query exports the result of a SELECT to Output.
+	Query  string
+	Output io.Writer
+	// OutputDir dumps every table in the database to OutputDir/<table>.csv,
+	// creating the directory if needed. Tables whose sanitized names collide
+	// ("a-b" and "a_b", or names differing only in case) get a numeric suffix:
+	// a_b.csv, a_b_2.csv.
+	OutputDir string
+	// NullValue is written for NULL (default empty).
+	NullValue string
+	// Blob encodes BLOB values (default BlobHex).
+	Blob      BlobEncoding
+	Delimiter rune
+	// Progress reports rows for Table, tables for OutputDir, and nothing for
+	// Query, whose row count isn't known up front.
+	Progress Progress
+}
+
+// FromSQLiteTable reports one exported table or query.
+type FromSQLiteTable struct {
+	Table   string   `json:"table,omitempty"`
+	Path    string   `json:"path,omitempty"` // set in OutputDir mode
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// FromSQLiteResult is returned from FromSQLite.
+type FromSQLiteResult struct {
+	Rows   int64             `json:"rows"` // across all tables
+	Tables []FromSQLiteTable `json:"tables"`
+}
+
+// FromSQLite exports a SQLite table, a SELECT, or every table in a database
+// to CSV with a header row. Integers and reals are written in their shortest
+// form, BLOBs as hex or base64. DATE, DATETIME and TIMESTAMP columns are
+// parsed by the driver and written back as "2006-01-02 15:04:05" (UTC, date
+// only at midnight) or RFC 3339 with their zone.
+func FromSQLite(ctx context.Context, opts FromSQLiteOptions) (FromSQLiteResult, error) {
+	var res FromSQLiteResult
+
+	if opts.DBPath == "" {
+		return res, fmt.Errorf("DBPath is required")
+	}
+	modes := 0
+	for _, s := range []string{opts.Table, opts.Query, opts.OutputDir} {
+		if s != "" {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return res, fmt.Errorf("exactly one of table, query or output dir is required")
+	}
+	if opts.OutputDir == "" && opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	switch opts.Blob {
+	case "":
+		opts.Blob = BlobHex
+	case BlobHex, BlobBase64:
+	default:
+		return res, fmt.Errorf("unknown blob encoding %q (want hex or base64)", opts.Blob)
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	// Opening a missing path would create an empty database.
+	if _, err := os.Stat(opts.DBPath); err != nil {
+		return res, fmt.Errorf("open database: %w", err)
+	}
+	db, err := sql.Open("sqlite", opts.DBPath)
+	if err != nil {
+		return res, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+
+	if opts.OutputDir == "" {
+		query, total := opts.Query, int64(0)
+		if opts.Table != "" {
+			query = "SELECT * FROM " + QuoteIdent(opts.Table)
+			if err := db.QueryRowContext(ctx, "SELECT count(*) FROM "+QuoteIdent(opts.Table)).Scan(&total); err != nil {
+				return res, fmt.Errorf("count rows: %w", err)
+			}
+		}
+		t, err := exportRows(ctx, db, query, opts.Output, opts, func(done int64) {
+			if total > 0 {
+				safeProgress(opts.Progress, done, total)
+			}
+		})
+		t.Table = opts.Table
+		res.Rows = t.Rows
+		res.Tables = append(res.Tables, t)
+		return res, err
+	}
+
+	tables, err := sqliteTables(ctx, db)
+	if err != nil {
+		return res, err
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		return res, fmt.Errorf("create output dir: %w", err)
+	}
+	used := make(map[string]bool, len(tables))
+	for i, name := range tables {
+		base := identSanitizer.ReplaceAllString(name, "_")
+		file := base
+		for n := 2; used[strings.ToLower(file)]; n++ {
+			file = base + "_" + strconv.Itoa(n)
+		}
+		used[strings.ToLower(file)] = true
+		path := filepath.Join(opts.OutputDir, file+".csv")
+		t, err := exportTableFile(ctx, db, name, path, opts)
+		if err != nil {
+			return res, fmt.Errorf("export %s: %w", name, err)
+		}
+		res.Rows += t.Rows
+		res.Tables = append(res.Tables, t)
+		safeProgress(opts.Progress, int64(i+1), int64(len(tables)))
+	}
+	return res, nil
+}
+
+func exportTableFile(ctx context.Context, db *sql.DB, table, path string, opts FromSQLiteOptions) (FromSQLiteTable, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return FromSQLiteTable{}, fmt.Errorf("create output: %w", err)
+	}
+	t, err := exportRows(ctx, db, "SELECT * FROM "+QuoteIdent(table), f, opts, nil)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	t.Table, t.Path = table, path
+	return t, err
+}
+
+// exportRows runs query and writes its header and rows as CSV to w.
+func exportRows(ctx context.Context, db *sql.DB, query string, w io.Writer, opts FromSQLiteOptions, onRow func(done int64)) (FromSQLiteTable, error) {
+	var t FromSQLiteTable
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return t, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+	if t.Columns, err = rows.Columns(); err != nil {
+		return t, err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.Delimiter
+	if err := writer.Write(t.Columns); err != nil {
+		return t, fmt.Errorf("write header: %w", err)
+	}
+	values := make([]any, len(t.Columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	rec := make([]string, len(values))
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return t, err
+		}
+		for i, v := range values {
+			rec[i] = sqliteText(v, opts)
+		}
+		if err := writer.Write(rec); err != nil {
+			return t, fmt.Errorf("write row: %w", err)
+		}
+		t.Rows++
+		if onRow != nil {
+			onRow(t.Rows)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return t, fmt.Errorf("query: %w", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return t, fmt.Errorf("writer: %w", err)
+	}
+	return t, nil
+}
+
+// sqliteText formats a value scanned from SQLite as a CSV field.
+func sqliteText(v any, opts FromSQLiteOptions) string {
+	switch v := v.(type) {
+	case nil:
+		return opts.NullValue
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return formatNumber(v)
+	case []byte:
+		if opts.Blob == BlobBase64 {
+			return base64.StdEncoding.EncodeToString(v)
+		}
+		return hex.EncodeToString(v)
+	case time.Time:
+		if v.Location() != time.UTC {
+			return v.Format(time.RFC3339Nano)
+		}
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	}
+	return fmt.Sprint(v)
+}
+
+// sqlQueryer is satisfied by *sql.DB and *sql.Conn.
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// sqliteTables lists the user tables of a database in name order.
+func sqliteTables(ctx context.Context, db sqlQueryer) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,131 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func fromSQLiteFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE items (id INTEGER, name TEXT, price REAL, data BLOB, added DATE)`,
+		`INSERT INTO items VALUES (1, 'a, b', 2.5, x'cafe', '2025-03-01'), (2, NULL, 10, NULL, NULL)`,
+		`CREATE TABLE "odd name" (v TEXT)`,
+		`INSERT INTO "odd name" VALUES ('x')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestFromSQLite_Table(t *testing.T) {
+	db := fromSQLiteFixture(t)
+
+	var buf bytes.Buffer
+	res, err := FromSQLite(context.Background(), FromSQLiteOptions{DBPath: db, Table: "items", Output: &buf, NullValue: `\N`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name,price,data,added\n1,\"a, b\",2.5,cafe,2025-03-01\n2,\\N,10,\\N,\\N\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	if res.Rows != 2 || res.Tables[0].Table != "items" {
+		t.Errorf("res = %+v", res)
+	}
+
+	buf.Reset()
+	if _, err := FromSQLite(context.Background(), FromSQLiteOptions{DBPath: db, Query: "SELECT data FROM items WHERE id = 1", Output: &buf, Blob: BlobBase64}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "data\nyv4=\n" {
+		t.Errorf("base64 query = %q", buf.String())
+	}
+}
+
+func TestFromSQLite_OutputDir(t *testing.T) {
+	db := fromSQLiteFixture(t)
+	dir := filepath.Join(t.TempDir(), "dump")
+
+	res, err := FromSQLite(context.Background(), FromSQLiteOptions{DBPath: db, OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tables) != 2 || res.Rows != 3 {
+		t.Fatalf("res = %+v", res)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "odd_name.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v\nx\n" {
+		t.Errorf("odd_name.csv = %q", got)
+	}
+}
+
+func TestFromSQLite_OutputDirNameCollision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, name := range []string{"a-b", "a_b", "A b"} {
+		if _, err := db.Exec("CREATE TABLE " + QuoteIdent(name) + " (v TEXT)"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("INSERT INTO "+QuoteIdent(name)+" VALUES (?)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join(t.TempDir(), "dump")
+
+	res, err := FromSQLite(context.Background(), FromSQLiteOptions{DBPath: path, OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"A b": "A_b.csv", "a-b": "a_b_2.csv", "a_b": "a_b_3.csv"}
+	if len(res.Tables) != len(want) {
+		t.Fatalf("res = %+v", res)
+	}
+	for _, tbl := range res.Tables {
+		if filepath.Base(tbl.Path) != want[tbl.Table] {
+			t.Errorf("%s written to %s, want %s", tbl.Table, filepath.Base(tbl.Path), want[tbl.Table])
+		}
+		got, err := os.ReadFile(tbl.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "v\n"+tbl.Table+"\n" {
+			t.Errorf("%s = %q", tbl.Path, got)
+		}
+	}
+}
+
+func TestFromSQLite_Validation(t *testing.T) {
+	db := fromSQLiteFixture(t)
+	var buf bytes.Buffer
+	for name, opts := range map[string]FromSQLiteOptions{
+		"no mode":     {DBPath: db, Output: &buf},
+		"two modes":   {DBPath: db, Table: "items", Query: "SELECT 1", Output: &buf},
+		"bad blob":    {DBPath: db, Table: "items", Output: &buf, Blob: "base32"},
+		"missing db":  {DBPath: filepath.Join(t.TempDir(), "nope.db"), Table: "items", Output: &buf},
+		"no such tbl": {DBPath: db, Table: "nope", Output: &buf},
+	} {
+		if _, err := FromSQLite(context.Background(), opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
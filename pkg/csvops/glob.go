@@ -0,0 +1,223 @@
+package csvops
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExpandGlob returns the files matching pattern in natural order. Besides
+// the filepath.Match syntax (*, ?, [...]) a "**" path segment matches any
+// number of directories, so "data/**/2025-*.csv" finds 2025 files at any
+// depth under data. A pattern without wildcards matches itself if it is a
+// regular file.
+func ExpandGlob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	segs := strings.Split(pattern, "/")
+	base := 0
+	for base < len(segs) && !hasGlobMeta(segs[base]) {
+		base++
+	}
+	if base == len(segs) {
+		info, err := os.Stat(pattern)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, nil
+		}
+		return []string{filepath.FromSlash(pattern)}, nil
+	}
+	for _, s := range segs[base:] {
+		if _, err := filepath.Match(s, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	root := strings.Join(segs[:base], "/")
+	if root == "" && strings.HasPrefix(pattern, "/") {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+	rest := segs[base:]
+
+	var out []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == filepath.FromSlash(root) {
+				if os.IsNotExist(err) {
+					return fs.SkipAll
+				}
+				return err
+			}
+			return nil // unreadable subdirectory
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if !globPrefixMatch(rest, parts) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && globMatch(rest, parts) {
+			out = append(out, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortNatural(out)
+	return out, nil
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// globMatch matches path segments against pattern segments, "**" matching
+// zero or more segments.
+func globMatch(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if globMatch(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// globPrefixMatch reports whether a directory at parts could contain a match,
+// so the walk can skip subtrees that cannot.
+func globPrefixMatch(pat, parts []string) bool {
+	for i, p := range parts {
+		if i >= len(pat) {
+			return false
+		}
+		if pat[i] == "**" {
+			return true
+		}
+		if ok, _ := filepath.Match(pat[i], p); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SortNatural sorts strings so that runs of digits compare by numeric value:
+// part_9 sorts before part_10.
+func SortNatural(s []string) {
+	sort.SliceStable(s, func(i, j int) bool { return naturalLess(s[i], s[j]) })
+}
+
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		if da && db {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			// Compare by value: strip leading zeros, then length, then digits.
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			a, b = ra, rb
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// listInputs lists the input files of a multi-file operation: files and
+// patterns in order without repeats, or else the *.csv files of dir (and its
+// subdirectories when recursive) in natural order.
+func listInputs(dir string, recursive bool, files, patterns []string) ([]string, error) {
+	if len(files) > 0 || len(patterns) > 0 {
+		out := append([]string{}, files...)
+		seen := make(map[string]bool)
+		for _, f := range out {
+			seen[filepath.Clean(f)] = true
+		}
+		for _, p := range patterns {
+			matches, err := ExpandGlob(p)
+			if err != nil {
+				return nil, fmt.Errorf("pattern %q: %w", p, err)
+			}
+			for _, m := range matches {
+				if !seen[filepath.Clean(m)] {
+					seen[filepath.Clean(m)] = true
+					out = append(out, m)
+				}
+			}
+		}
+		return out, nil
+	}
+
+	if dir == "" {
+		return nil, fmt.Errorf("either InputDir, InputFiles or Patterns is required")
+	}
+	isCSV := func(name string) bool { return strings.HasSuffix(strings.ToLower(name), ".csv") }
+	var out []string
+	if recursive {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() && isCSV(d.Name()) {
+				out = append(out, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read input dir: %w", err)
+		}
+	} else {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("read input dir: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isCSV(e.Name()) {
+				out = append(out, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	SortNatural(out)
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package csvops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSortNatural(t *testing.T) {
+	got := []string{"part_10.csv", "part_9.csv", "part_1.csv", "part_02.csv", "part_2.csv", "b.csv", "a10b2", "a10b10"}
+	SortNatural(got)
+	want := "a10b2 a10b10 b.csv part_1.csv part_2.csv part_02.csv part_9.csv part_10.csv"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got  %s\nwant %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestExpandGlob_DoubleStar(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"2025-01.csv",
+		"x/2025-02.csv",
+		"x/y/2025-10.csv",
+		"x/y/2024-12.csv",
+		"x/y/2025-03.txt",
+		"z/2025-04.csv",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeCSV(t, path, "a\n")
+	}
+
+	rel := func(paths []string) string {
+		out := make([]string, len(paths))
+		for i, p := range paths {
+			r, _ := filepath.Rel(dir, p)
+			out[i] = filepath.ToSlash(r)
+		}
+		return strings.Join(out, " ")
+	}
+	for pattern, want := range map[string]string{
+		"**/2025-*.csv":   "2025-01.csv x/2025-02.csv x/y/2025-10.csv z/2025-04.csv",
+		"x/**/*.csv":      "x/2025-02.csv x/y/2024-12.csv x/y/2025-10.csv",
+		"*/2025-*.csv":    "x/2025-02.csv z/2025-04.csv",
+		"x/y/2025-10.csv": "x/y/2025-10.csv",
+		"nope/**/*.csv":   "",
+	} {
+		got, err := ExpandGlob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			t.Fatalf("%s: %v", pattern, err)
+		}
+		if rel(got) != want {
+			t.Errorf("%s:\n got %s\nwant %s", pattern, rel(got), want)
+		}
+	}
+
+	if _, err := ExpandGlob(filepath.Join(dir, "[.csv")); err == nil {
+		t.Error("expected a bad pattern error")
+	}
+}
@@ -0,0 +1,165 @@
+package csvops
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HistogramKind selects how numeric histogram bins are laid out.
+type HistogramKind string
+
+const (
+	// HistogramEqualWidth splits [min, max] into bins of the same width.
+	HistogramEqualWidth HistogramKind = "equal-width"
+	// HistogramQuantile places bin edges at quantiles, so each bin holds
+	// roughly the same number of values.
+	HistogramQuantile HistogramKind = "quantile"
+)
+
+// DefaultHistogramBins is used when StatsOptions.Bins is unset.
+const DefaultHistogramBins = 10
+
+// HistogramBin is one bar of a histogram. Numeric and length bins cover
+// [Low, High), except the last which includes High; class bins only have a
+// Label.
+type HistogramBin struct {
+	Label string  `json:"label"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count int64   `json:"count"`
+}
+
+// numericHistogram builds a histogram of the values in sk, scaling the
+// sketch's ranks to n values. Counts are exact while the sketch has not
+// compacted anything and approximate beyond that.
+func numericHistogram(sk *kllSketch, n int64, lo, hi float64, kind HistogramKind, bins int) []HistogramBin {
+	if lo == hi {
+		return []HistogramBin{{Label: formatEdge(lo), Low: lo, High: hi, Count: n}}
+	}
+	edges := make([]float64, 0, bins+1)
+	if kind == HistogramQuantile {
+		qs := make([]float64, bins+1)
+		for i := range qs {
+			qs[i] = float64(i) / float64(bins)
+		}
+		for _, e := range sk.quantiles(qs...) {
+			if len(edges) == 0 || e > edges[len(edges)-1] {
+				edges = append(edges, e)
+			}
+		}
+		edges[0] = lo
+		if len(edges) == 1 {
+			edges = append(edges, hi)
+		}
+		edges[len(edges)-1] = hi
+	} else {
+		for i := 0; i <= bins; i++ {
+			edges = append(edges, lo+(hi-lo)*float64(i)/float64(bins))
+		}
+		edges[bins] = hi // avoid floating-point drift on the last edge
+	}
+
+	// Round cumulative counts rather than each bin so the bins sum to n.
+	out := make([]HistogramBin, len(edges)-1)
+	prev := int64(0)
+	for i := range out {
+		cum := n
+		if i < len(out)-1 {
+			cum = int64(sk.rankBelow(edges[i+1])*float64(n) + 0.5)
+		}
+		out[i] = HistogramBin{
+			Label: fmt.Sprintf("%s – %s", formatEdge(edges[i]), formatEdge(edges[i+1])),
+			Low:   edges[i],
+			High:  edges[i+1],
+			Count: cum - prev,
+		}
+		prev = cum
+	}
+	return out
+}
+
+func formatEdge(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// lengthHistogram buckets exact per-length counts. Up to bins distinct lengths
+// get a bin each; otherwise [min, max] is split into equal integer ranges.
+func lengthHistogram(lengths map[int]int64, minLen, maxLen, bins int) []HistogramBin {
+	if len(lengths) == 0 {
+		return nil
+	}
+	if maxLen-minLen < bins {
+		out := make([]HistogramBin, 0, len(lengths))
+		for l := minLen; l <= maxLen; l++ {
+			if n := lengths[l]; n > 0 {
+				out = append(out, HistogramBin{Label: strconv.Itoa(l), Low: float64(l), High: float64(l), Count: n})
+			}
+		}
+		return out
+	}
+	width := (maxLen - minLen + bins) / bins // ceil((max-min+1) / bins)
+	out := make([]HistogramBin, 0, bins)
+	for lo := minLen; lo <= maxLen; lo += width {
+		hi := min(lo+width-1, maxLen)
+		b := HistogramBin{Label: fmt.Sprintf("%d–%d", lo, hi), Low: float64(lo), High: float64(hi)}
+		for l := lo; l <= hi; l++ {
+			b.Count += lengths[l]
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// Character classes, combined as a bit set per value.
+const (
+	classLetters = 1 << iota
+	classDigits
+	classSpaces
+	classSymbols
+)
+
+var classNames = []string{"letters", "digits", "spaces", "symbols"}
+
+// charClasses reports which character classes occur in v and its length in
+// runes.
+func charClasses(v string) (classes uint8, length int) {
+	for _, r := range v {
+		length++
+		switch {
+		case unicode.IsLetter(r):
+			classes |= classLetters
+		case unicode.IsDigit(r):
+			classes |= classDigits
+		case unicode.IsSpace(r):
+			classes |= classSpaces
+		default:
+			classes |= classSymbols
+		}
+	}
+	return classes, length
+}
+
+// classHistogram labels each class combination ("letters+digits") and sorts
+// the bins by count, largest first.
+func classHistogram(counts map[uint8]int64) []HistogramBin {
+	out := make([]HistogramBin, 0, len(counts))
+	for set, n := range counts {
+		var parts []string
+		for i, name := range classNames {
+			if set&(1<<i) != 0 {
+				parts = append(parts, name)
+			}
+		}
+		out = append(out, HistogramBin{Label: strings.Join(parts, "+"), Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
@@ -0,0 +1,270 @@
+package csvops
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// IndexExt is appended to a CSV path to name its sidecar index file.
+const IndexExt = ".csvidx"
+
+// DefaultIndexInterval is how many data rows apart index offsets are
+// recorded when IndexOptions.Every is unset.
+const DefaultIndexInterval = 1000
+
+// indexMagic identifies (and versions) the sidecar format.
+var indexMagic = [8]byte{'C', 'S', 'V', 'I', 'D', 'X', '1', '\n'}
+
+// ErrStaleIndex is returned by LoadIndex when the sidecar does not match the
+// CSV file's current size or modification time.
+var ErrStaleIndex = errors.New("index is stale")
+
+// IndexOptions configures a BuildIndex operation.
+type IndexOptions struct {
+	Input string
+	// Every records a byte offset every Every data rows. Smaller values make
+	// ReadRange parse fewer rows per call at the cost of a larger index.
+	Every     int
+	Delimiter rune
+	// NoSidecar keeps the index in memory instead of writing Input.csvidx.
+	NoSidecar bool
+	Progress  Progress // reports bytes scanned out of the file size
+}
+
+// Index maps data row numbers to byte offsets in a CSV file. Offsets[i] is
+// where data row i*Every (0-based, header excluded) starts. Offsets come from
+// the CSV parser, so quoted fields containing newlines are handled correctly.
+type Index struct {
+	Size      int64   `json:"size"`     // file size when indexed
+	ModTime   int64   `json:"mod_time"` // file mtime (UnixNano) when indexed
+	Every     int     `json:"every"`
+	Delimiter rune    `json:"delimiter"`
+	Rows      int64   `json:"rows"` // data rows, header excluded
+	Offsets   []int64 `json:"offsets"`
+}
+
+// indexHeader is the fixed-size part of the sidecar file.
+type indexHeader struct {
+	Magic     [8]byte
+	Size      int64
+	ModTime   int64
+	Every     int64
+	Delimiter int64
+	Rows      int64
+	Count     int64
+}
+
+// BuildIndex scans opts.Input once and records the byte offset of every
+// Every-th data row, writing it to Input + ".csvidx" unless NoSidecar is set.
+func BuildIndex(ctx context.Context, opts IndexOptions) (*Index, error) {
+	if opts.Input == "" {
+		return nil, fmt.Errorf("input is required")
+	}
+	if opts.Every <= 0 {
+		opts.Every = DefaultIndexInterval
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{
+		Size:      st.Size(),
+		ModTime:   st.ModTime().UnixNano(),
+		Every:     opts.Every,
+		Delimiter: opts.Delimiter,
+	}
+
+	r := csv.NewReader(f)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	if _, err := r.Read(); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		off := r.InputOffset()
+		_, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue // unparseable rows are skipped, as in CountDataRows
+		}
+		if idx.Rows%int64(opts.Every) == 0 {
+			idx.Offsets = append(idx.Offsets, off)
+		}
+		idx.Rows++
+		safeProgress(opts.Progress, off, idx.Size)
+	}
+
+	if !opts.NoSidecar {
+		if err := idx.write(opts.Input + IndexExt); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func (idx *Index) write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	hdr := indexHeader{
+		Magic:     indexMagic,
+		Size:      idx.Size,
+		ModTime:   idx.ModTime,
+		Every:     int64(idx.Every),
+		Delimiter: int64(idx.Delimiter),
+		Rows:      idx.Rows,
+		Count:     int64(len(idx.Offsets)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, hdr); err != nil {
+		f.Close()
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := binary.Write(w, binary.LittleEndian, idx.Offsets); err != nil {
+		f.Close()
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write index: %w", err)
+	}
+	return f.Close()
+}
+
+// LoadIndex reads the sidecar index for input. It returns ErrStaleIndex when
+// the CSV has changed since it was indexed.
+func LoadIndex(input string) (*Index, error) {
+	st, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(input + IndexExt)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var hdr indexHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	if hdr.Magic != indexMagic || hdr.Every <= 0 || hdr.Count < 0 || hdr.Count > hdr.Rows/hdr.Every+1 {
+		return nil, fmt.Errorf("read index: not a csvops index file")
+	}
+	if hdr.Size != st.Size() || hdr.ModTime != st.ModTime().UnixNano() {
+		return nil, ErrStaleIndex
+	}
+	idx := &Index{
+		Size:      hdr.Size,
+		ModTime:   hdr.ModTime,
+		Every:     int(hdr.Every),
+		Delimiter: rune(hdr.Delimiter),
+		Rows:      hdr.Rows,
+		Offsets:   make([]int64, hdr.Count),
+	}
+	if err := binary.Read(r, binary.LittleEndian, idx.Offsets); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	return idx, nil
+}
+
+// RangeResult is returned from ReadRange.
+type RangeResult struct {
+	Headers   []string   `json:"headers"`
+	Rows      [][]string `json:"rows"`
+	TotalRows int64      `json:"total_rows"` // data rows in the whole file, from the index
+}
+
+// ReadRange returns up to limit data rows starting at the 0-based data row
+// offset. It seeks via the sidecar index, (re)building it with the default
+// interval and comma delimiter when it is missing or stale, so only the
+// rows between the nearest indexed offset and the window are parsed.
+func ReadRange(path string, offset, limit int64) (RangeResult, error) {
+	var res RangeResult
+	if offset < 0 || limit < 0 {
+		return res, fmt.Errorf("offset and limit must not be negative")
+	}
+
+	idx, err := LoadIndex(path)
+	if err != nil {
+		idx, err = BuildIndex(context.Background(), IndexOptions{Input: path})
+		if err != nil {
+			// e.g. a read-only directory: fall back to an in-memory index.
+			idx, err = BuildIndex(context.Background(), IndexOptions{Input: path, NoSidecar: true})
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	res.TotalRows = idx.Rows
+
+	f, err := os.Open(path)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+
+	hr := csv.NewReader(f)
+	hr.Comma = idx.Delimiter
+	hr.FieldsPerRecord = -1
+	res.Headers, err = hr.Read()
+	if err == io.EOF {
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("read header: %w", err)
+	}
+
+	if offset >= idx.Rows || limit == 0 {
+		return res, nil
+	}
+	block := offset / int64(idx.Every)
+	start := idx.Offsets[block]
+	skip := offset - block*int64(idx.Every)
+
+	r := csv.NewReader(io.NewSectionReader(f, start, idx.Size-start))
+	r.Comma = idx.Delimiter
+	r.FieldsPerRecord = -1
+
+	res.Rows = make([][]string, 0, min(limit, idx.Rows-offset))
+	for int64(len(res.Rows)) < limit {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		res.Rows = append(res.Rows, rec)
+	}
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package csvops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildIndex_OffsetsSkipQuotedNewlines(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	body := "id,note\n1,a\n2,\"x\ny\"\n3,c\n4,d\n5,e\n"
+	writeCSV(t, in, body)
+
+	idx, err := BuildIndex(context.Background(), IndexOptions{Input: in, Every: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Rows != 5 {
+		t.Errorf("Rows = %d, want 5", idx.Rows)
+	}
+	want := []int64{
+		int64(strings.Index(body, "1,a")),
+		int64(strings.Index(body, "3,c")),
+		int64(strings.Index(body, "5,e")),
+	}
+	if !reflect.DeepEqual(idx.Offsets, want) {
+		t.Errorf("Offsets = %v, want %v", idx.Offsets, want)
+	}
+
+	loaded, err := LoadIndex(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, idx) {
+		t.Errorf("LoadIndex = %+v, want %+v", loaded, idx)
+	}
+}
+
+func TestLoadIndex_StaleAfterModification(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n")
+	if _, err := BuildIndex(context.Background(), IndexOptions{Input: in}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeCSV(t, in, "id\n1\n2\n")
+	if _, err := LoadIndex(in); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("size change: err = %v, want ErrStaleIndex", err)
+	}
+
+	if _, err := BuildIndex(context.Background(), IndexOptions{Input: in}); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(in, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIndex(in); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("mtime change: err = %v, want ErrStaleIndex", err)
+	}
+}
+
+func TestReadRange_SeeksViaIndex(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("id,note\n")
+	for i := 0; i < 2500; i++ {
+		if i%7 == 0 {
+			fmt.Fprintf(&b, "%d,\"multi\nline\"\n", i)
+		} else {
+			fmt.Fprintf(&b, "%d,x\n", i)
+		}
+	}
+	writeCSV(t, in, b.String())
+
+	res, err := ReadRange(in, 1998, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRows != 2500 {
+		t.Errorf("TotalRows = %d, want 2500", res.TotalRows)
+	}
+	if !reflect.DeepEqual(res.Headers, []string{"id", "note"}) {
+		t.Errorf("Headers = %v", res.Headers)
+	}
+	var ids []string
+	for _, r := range res.Rows {
+		ids = append(ids, r[0])
+	}
+	if !reflect.DeepEqual(ids, []string{"1998", "1999", "2000", "2001"}) {
+		t.Errorf("ids = %v", ids)
+	}
+	if _, err := os.Stat(in + IndexExt); err != nil {
+		t.Errorf("sidecar not written: %v", err)
+	}
+
+	res, err = ReadRange(in, 2498, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 2 {
+		t.Errorf("tail window rows = %d, want 2", len(res.Rows))
+	}
+	res, err = ReadRange(in, 5000, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 0 {
+		t.Errorf("past-end rows = %d, want 0", len(res.Rows))
+	}
+}
+
+func TestReadRange_RebuildsStaleIndex(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n2\n")
+	if _, err := ReadRange(in, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	writeCSV(t, in, "id\n1\n2\n3\n4\n")
+	res, err := ReadRange(in, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRows != 4 || len(res.Rows) != 1 || res.Rows[0][0] != "4" {
+		t.Errorf("res = %+v", res)
+	}
+}
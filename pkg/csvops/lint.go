@@ -0,0 +1,395 @@
+package csvops
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LintOptions configures a Lint operation.
+type LintOptions struct {
+	Input     string
+	Delimiter rune
+	// Output, when set, receives a normalized copy of the file (the --fix
+	// mode): properly quoted fields, LF line endings, UTF-8 text without
+	// invisible characters, unique non-empty header names and rows padded or
+	// trimmed to the header width where that loses no data.
+	Output io.Writer
+	// MaxIssues caps how many issues are kept in the result (0 = all).
+	// Counting continues past the cap.
+	MaxIssues int
+	Progress  Progress // done and total are in bytes
+}
+
+// LintIssue is one structural problem. Line is 1-based and Offset is the
+// 0-based byte offset in the file.
+type LintIssue struct {
+	Line    int64  `json:"line"`
+	Offset  int64  `json:"offset"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// LintResult is returned from Lint.
+type LintResult struct {
+	Rows       int64            `json:"rows"` // data records, excluding the header
+	Bytes      int64            `json:"bytes"`
+	LineEnding string           `json:"line_ending"` // LF, CRLF, CR or mixed
+	IssueCount int64            `json:"issue_count"`
+	Counts     map[string]int64 `json:"counts"` // issues per kind
+	Issues     []LintIssue      `json:"issues"`
+	Clean      bool             `json:"clean"`
+}
+
+// invisibleRunes maps characters that look like nothing (or like a space) to
+// their replacement in fixed output; -1 drops the character.
+var invisibleRunes = map[rune]rune{
+	'\u00a0': ' ', // no-break space
+	'\u2007': ' ', // figure space
+	'\u202f': ' ', // narrow no-break space
+	'\u00ad': -1,  // soft hyphen
+	'\u200b': -1,  // zero-width space
+	'\u200c': -1,  // zero-width non-joiner
+	'\u200d': -1,  // zero-width joiner
+	'\u2060': -1,  // word joiner
+	'\ufeff': -1,  // byte order mark / zero-width no-break space
+}
+
+// Lint scans the raw bytes of a CSV file, without encoding/csv, and reports
+// structural problems that other operations tolerate silently. Issue kinds
+// are field-count, trailing-delimiter, bare-quote, unterminated-quote,
+// mixed-line-endings, empty-header, duplicate-header, invisible-character and
+// invalid-utf8. Parsing is lenient: a stray quote is kept as a literal
+// character, and invalid bytes are read as Latin-1.
+func Lint(ctx context.Context, opts LintOptions) (LintResult, error) {
+	res := LintResult{Counts: map[string]int64{}}
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return res, fmt.Errorf("stat input: %w", err)
+	}
+
+	l := &linter{
+		br:      bufio.NewReaderSize(f, 64<<10),
+		delim:   opts.Delimiter,
+		line:    1,
+		res:     &res,
+		max:     opts.MaxIssues,
+		endings: map[string]int64{},
+	}
+	var w *csv.Writer
+	if opts.Output != nil {
+		w = csv.NewWriter(opts.Output)
+		w.Comma = opts.Delimiter
+	}
+
+	var width int
+	header := true
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		rec, err := l.readRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("read input: %w", err)
+		}
+
+		fields := rec.fields
+		if header {
+			header = false
+			width = len(fields)
+			fields = l.checkHeader(rec)
+		} else {
+			res.Rows++
+			fields = l.checkWidth(rec, width)
+		}
+		if w != nil {
+			if err := w.Write(fields); err != nil {
+				return res, fmt.Errorf("write output: %w", err)
+			}
+		}
+		safeProgress(opts.Progress, l.offset, info.Size())
+	}
+	l.checkLineEndings()
+
+	if w != nil {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return res, fmt.Errorf("flush output: %w", err)
+		}
+	}
+	res.Bytes = l.offset
+	res.Clean = res.IssueCount == 0
+	return res, nil
+}
+
+// lintRecord is one parsed record with its position.
+type lintRecord struct {
+	fields     []string
+	line       int64
+	offset     int64
+	lastQuoted bool // the last field was quoted
+}
+
+type linter struct {
+	br     *bufio.Reader
+	delim  rune
+	line   int64 // current line, 1-based
+	offset int64 // offset of the next rune
+	res    *LintResult
+	max    int
+
+	endings      map[string]int64
+	firstEnding  string
+	deviation    LintIssue // first line ending that differs from the first one
+	hasDeviation bool
+}
+
+func (l *linter) issue(line, offset int64, kind, msg string) {
+	l.res.IssueCount++
+	l.res.Counts[kind]++
+	if l.max <= 0 || len(l.res.Issues) < l.max {
+		l.res.Issues = append(l.res.Issues, LintIssue{Line: line, Offset: offset, Kind: kind, Message: msg})
+	}
+}
+
+// next reads one rune and its offset, reporting invalid UTF-8 and invisible
+// characters. keep is the rune for fixed output, or -1 to drop it.
+func (l *linter) next() (r, keep rune, off int64, err error) {
+	r, size, err := l.br.ReadRune()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	off = l.offset
+	l.offset += int64(size)
+	if r == utf8.RuneError && size == 1 {
+		_ = l.br.UnreadRune()
+		b, _ := l.br.ReadByte()
+		l.issue(l.line, off, "invalid-utf8", fmt.Sprintf("invalid UTF-8 byte 0x%02X", b))
+		return rune(b), rune(b), off, nil
+	}
+	if repl, ok := invisibleRunes[r]; ok {
+		l.issue(l.line, off, "invisible-character", fmt.Sprintf("invisible character %U", r))
+		return r, repl, off, nil
+	}
+	return r, r, off, nil
+}
+
+const (
+	lintFieldStart = iota
+	lintUnquoted
+	lintQuoted
+	lintQuoteInQuoted // saw a quote inside a quoted field: closing or escaped
+)
+
+// readRecord parses the next record, skipping blank lines like encoding/csv.
+func (l *linter) readRecord() (lintRecord, error) {
+	rec := lintRecord{line: l.line, offset: l.offset}
+	var buf strings.Builder
+	state := lintFieldStart
+	started := false
+	push := func() {
+		rec.fields = append(rec.fields, buf.String())
+		rec.lastQuoted = state == lintQuoted || state == lintQuoteInQuoted
+		buf.Reset()
+		state = lintFieldStart
+	}
+	add := func(r rune) {
+		if r >= 0 {
+			buf.WriteRune(r)
+		}
+	}
+	var quoteOff int64 // offset of the last quote seen in a quoted field
+	for {
+		r, keep, off, err := l.next()
+		if err == io.EOF {
+			if !started {
+				return rec, io.EOF
+			}
+			if state == lintQuoted {
+				l.issue(rec.line, rec.offset, "unterminated-quote", "quoted field is never closed; the rest of the file was read as one field")
+			}
+			push()
+			return rec, nil
+		}
+		if err != nil {
+			return rec, err
+		}
+		isNewline := r == '\n' || r == '\r'
+		if !started && state == lintFieldStart && isNewline {
+			// Blank line.
+			l.lineEnd(r)
+			rec.line, rec.offset = l.line, l.offset
+			continue
+		}
+		started = true
+
+		switch state {
+		case lintFieldStart, lintUnquoted:
+			switch {
+			case keep < 0 && state == lintFieldStart:
+				// Dropped character, e.g. a BOM before a quoted header.
+			case r == '"' && state == lintFieldStart:
+				state = lintQuoted
+			case r == '"':
+				l.issue(l.line, off, "bare-quote", "quote in an unquoted field")
+				add(keep)
+			case r == l.delim:
+				push()
+			case isNewline:
+				push()
+				l.lineEnd(r)
+				return rec, nil
+			default:
+				state = lintUnquoted
+				add(keep)
+			}
+		case lintQuoted:
+			if r == '"' {
+				state = lintQuoteInQuoted
+				quoteOff = off
+				continue
+			}
+			if r == '\n' {
+				l.line++
+			}
+			add(keep)
+		case lintQuoteInQuoted:
+			switch {
+			case r == '"':
+				add('"')
+				state = lintQuoted
+			case r == l.delim:
+				push()
+			case isNewline:
+				push()
+				l.lineEnd(r)
+				return rec, nil
+			default:
+				l.issue(l.line, quoteOff, "bare-quote", "quote in a quoted field is not doubled")
+				add('"')
+				add(keep)
+				state = lintQuoted
+			}
+		}
+	}
+}
+
+// lineEnd consumes the rest of a record terminator starting with r and
+// records its style.
+func (l *linter) lineEnd(r rune) {
+	line, off := l.line, l.offset-1
+	ending := "LF"
+	if r == '\r' {
+		ending = "CR"
+		if b, err := l.br.Peek(1); err == nil && b[0] == '\n' {
+			_, _ = l.br.ReadByte()
+			l.offset++
+			ending = "CRLF"
+		}
+	}
+	l.line++
+	l.endings[ending]++
+	if l.firstEnding == "" {
+		l.firstEnding = ending
+	} else if ending != l.firstEnding && !l.hasDeviation {
+		l.hasDeviation = true
+		l.deviation = LintIssue{Line: line, Offset: off}
+		l.deviation.Message = fmt.Sprintf("line ends with %s but line 1 ends with %s", ending, l.firstEnding)
+	}
+}
+
+func (l *linter) checkLineEndings() {
+	switch len(l.endings) {
+	case 0:
+		return
+	case 1:
+		l.res.LineEnding = l.firstEnding
+		return
+	}
+	l.res.LineEnding = "mixed"
+	kinds := make([]string, 0, len(l.endings))
+	for k, n := range l.endings {
+		kinds = append(kinds, fmt.Sprintf("%s: %d", k, n))
+	}
+	sort.Strings(kinds)
+	d := l.deviation
+	l.issue(d.Line, d.Offset, "mixed-line-endings", fmt.Sprintf("%s (%s)", d.Message, strings.Join(kinds, ", ")))
+}
+
+// checkHeader reports empty and duplicate names and returns the fixed header:
+// empty names become column_N and repeats get a _2, _3 … suffix, skipping
+// any name already in the header.
+func (l *linter) checkHeader(rec lintRecord) []string {
+	fixed := make([]string, len(rec.fields))
+	seen := make(map[string]bool, len(rec.fields))
+	taken := make(map[string]bool, len(rec.fields)) // names in the header or given out
+	for _, name := range rec.fields {
+		taken[strings.TrimSpace(name)] = true
+	}
+	for i, name := range rec.fields {
+		trimmed := strings.TrimSpace(name)
+		base := ""
+		if trimmed == "" {
+			l.issue(rec.line, rec.offset, "empty-header", fmt.Sprintf("column %d has no name", i+1))
+			base = fmt.Sprintf("column_%d", i+1)
+		} else if seen[trimmed] {
+			l.issue(rec.line, rec.offset, "duplicate-header", fmt.Sprintf("column %d repeats the name %q", i+1, trimmed))
+			base = name
+		}
+		seen[trimmed] = true
+		if base != "" {
+			name = base
+			for n := 2; taken[strings.TrimSpace(name)]; n++ {
+				name = fmt.Sprintf("%s_%d", base, n)
+			}
+			taken[strings.TrimSpace(name)] = true
+		}
+		fixed[i] = name
+	}
+	return fixed
+}
+
+// checkWidth reports records whose field count differs from the header's and
+// returns them padded with empty fields, or with trailing empty fields
+// trimmed, when that loses no data.
+func (l *linter) checkWidth(rec lintRecord, width int) []string {
+	fields := rec.fields
+	n := len(fields)
+	switch {
+	case n == width:
+		return fields
+	case n == width+1 && fields[n-1] == "" && !rec.lastQuoted:
+		l.issue(rec.line, rec.offset, "trailing-delimiter", "record ends with a delimiter")
+	default:
+		l.issue(rec.line, rec.offset, "field-count", fmt.Sprintf("record has %d fields, header has %d", n, width))
+	}
+	for len(fields) > width && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	for len(fields) < width {
+		fields = append(fields, "")
+	}
+	return fields
+}
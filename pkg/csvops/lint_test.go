@@ -0,0 +1,99 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint_ReportsStructuralIssues(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "\ufeffid,name,,name\n"+ // BOM, empty and duplicate header
+		"1,a\"b,x,y\n"+ // bare quote
+		"2,\"say \"hi\" now\",x,y\r\n"+ // undoubled quotes in quoted field, CRLF
+		"3,c,x,y,\n"+ // trailing delimiter
+		"4,d\u00a0e\u200b,x\n"+ // NBSP, zero-width space, short row
+		"5,caf\xe9,x,y\n"+ // Latin-1 byte
+		"6,\"open,x,y\n")
+
+	var fixed bytes.Buffer
+	res, err := Lint(context.Background(), LintOptions{Input: in, Output: &fixed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Clean || res.Rows != 6 || res.LineEnding != "mixed" {
+		t.Fatalf("res = %+v", res)
+	}
+
+	var got []string
+	for _, is := range res.Issues {
+		got = append(got, fmt.Sprintf("%d:%d %s", is.Line, is.Offset, is.Kind))
+	}
+	want := []string{
+		"1:0 invisible-character",
+		"1:0 empty-header",
+		"1:0 duplicate-header",
+		"2:20 bare-quote",
+		"3:34 bare-quote",
+		"3:37 bare-quote",
+		"4:49 trailing-delimiter",
+		"5:61 invisible-character",
+		"5:64 invisible-character",
+		"5:58 field-count",
+		"6:75 invalid-utf8",
+		"7:81 unterminated-quote",
+		"7:81 field-count",
+		"3:47 mixed-line-endings",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if res.Counts["invisible-character"] != 3 || res.IssueCount != int64(len(want)) {
+		t.Errorf("counts = %v", res.Counts)
+	}
+
+	wantFixed := "id,name,column_3,name_2\n" +
+		"1,\"a\"\"b\",x,y\n" +
+		"2,\"say \"\"hi\"\" now\",x,y\n" +
+		"3,c,x,y\n" +
+		"4,d e,x,\n" +
+		"5,café,x,y\n" +
+		"6,\"open,x,y\n\",,\n"
+	if fixed.String() != wantFixed {
+		t.Errorf("fixed:\n%q\nwant:\n%q", fixed.String(), wantFixed)
+	}
+}
+
+func TestLint_FixedHeaderNamesAreUnique(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "a,a,a_2,,column_4\n1,2,3,4,5\n")
+
+	var fixed bytes.Buffer
+	res, err := Lint(context.Background(), LintOptions{Input: in, Output: &fixed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Counts["duplicate-header"] != 1 || res.Counts["empty-header"] != 1 {
+		t.Errorf("counts = %v", res.Counts)
+	}
+	if want := "a,a_3,a_2,column_4_2,column_4\n1,2,3,4,5\n"; fixed.String() != want {
+		t.Errorf("fixed = %q, want %q", fixed.String(), want)
+	}
+}
+
+func TestLint_CleanFile(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "a,b\r\n1,\"x\r\ny\"\r\n\r\n2,\"\"\"q\"\"\"\r\n")
+
+	res, err := Lint(context.Background(), LintOptions{Input: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Clean || res.Rows != 2 || res.LineEnding != "CRLF" {
+		t.Errorf("res = %+v", res)
+	}
+}
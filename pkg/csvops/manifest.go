@@ -0,0 +1,197 @@
+package csvops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSplitTemplate is the file name template used when
+// SplitOptions.NameTemplate is empty in the chunking modes.
+const DefaultSplitTemplate = "part_{n}.csv"
+
+// ManifestFile is the name of the manifest Split writes into OutputDir.
+const ManifestFile = "manifest.json"
+
+// SplitFile describes one output file written by Split.
+type SplitFile struct {
+	Path string `json:"path"`
+	// Rows counts the data rows in the file. For a skipped file they are
+	// counted from the existing file, not from this run's input, so Rows,
+	// Bytes and SHA256 always describe the same file.
+	Rows   int64    `json:"rows"`
+	Bytes  int64    `json:"bytes"`
+	SHA256 string   `json:"sha256"`
+	Values []string `json:"values,omitempty"` // partition mode only
+	// MergedValues lists other partition values that share this file; see
+	// PartitionInfo.MergedValues.
+	MergedValues [][]string `json:"merged_values,omitempty"`
+	Skipped      bool       `json:"skipped,omitempty"` // file existed and IfExists was skip
+}
+
+// SplitManifest is the content of manifest.json. File paths are relative to
+// the manifest's directory, with forward slashes.
+type SplitManifest struct {
+	Input     string      `json:"input"`
+	CreatedAt time.Time   `json:"created_at"`
+	Rows      int64       `json:"rows"`
+	Files     []SplitFile `json:"files"`
+}
+
+var splitTemplateToken = regexp.MustCompile(`\{([a-z]+)(?::([^}]*))?\}`)
+
+// splitNamer renders SplitOptions.NameTemplate. Supported tokens:
+//
+//	{n}      1-based part (or partition) number; {n:04} zero-pads to 4 digits
+//	{stem}   input file name without extension
+//	{value}  sanitized partition value(s), joined with "_"
+//	{ts}     run start time as 20060102T150405 (UTC)
+type splitNamer struct {
+	tmpl string
+	stem string
+	ts   string
+}
+
+func newSplitNamer(tmpl, input string, start time.Time) (*splitNamer, error) {
+	for _, m := range splitTemplateToken.FindAllStringSubmatch(tmpl, -1) {
+		switch m[1] {
+		case "n":
+			if m[2] != "" {
+				if _, err := strconv.Atoi(m[2]); err != nil {
+					return nil, fmt.Errorf("name template: invalid width in %s", m[0])
+				}
+			}
+		case "stem", "value", "ts":
+			if m[2] != "" {
+				return nil, fmt.Errorf("name template: %s does not take a format", m[0])
+			}
+		default:
+			return nil, fmt.Errorf("name template: unknown token %s", m[0])
+		}
+	}
+	base := filepath.Base(input)
+	return &splitNamer{
+		tmpl: tmpl,
+		stem: strings.TrimSuffix(base, filepath.Ext(base)),
+		ts:   start.UTC().Format("20060102T150405"),
+	}, nil
+}
+
+func (n *splitNamer) name(part int, values []string) string {
+	return splitTemplateToken.ReplaceAllStringFunc(n.tmpl, func(tok string) string {
+		m := splitTemplateToken.FindStringSubmatch(tok)
+		switch m[1] {
+		case "n":
+			if m[2] != "" {
+				width, _ := strconv.Atoi(m[2])
+				if strings.HasPrefix(m[2], "0") {
+					return fmt.Sprintf("%0*d", width, part)
+				}
+				return fmt.Sprintf("%*d", width, part)
+			}
+			return strconv.Itoa(part)
+		case "stem":
+			return n.stem
+		case "value":
+			vals := make([]string, len(values))
+			for i, v := range values {
+				vals[i] = SanitizeFileName(v)
+			}
+			return strings.Join(vals, "_")
+		case "ts":
+			return n.ts
+		}
+		return tok
+	})
+}
+
+// checkIfExists applies the IfExists policy to path before it is created.
+// It reports skip=true when the existing file must be left untouched.
+func checkIfExists(path string, policy IfExistsAction) (skip bool, err error) {
+	if policy == IfExistsReplace {
+		return false, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if policy == IfExistsSkip {
+		return true, nil
+	}
+	return false, fmt.Errorf("%s already exists", path)
+}
+
+// fileDigest counts and hashes every byte written through it so Split can
+// report size and SHA-256 without re-reading its outputs.
+type fileDigest struct {
+	h hash.Hash
+	n int64
+}
+
+func newFileDigest() *fileDigest { return &fileDigest{h: sha256.New()} }
+
+func (d *fileDigest) Write(p []byte) (int, error) {
+	d.h.Write(p)
+	d.n += int64(len(p))
+	return len(p), nil
+}
+
+func (d *fileDigest) sum() string { return hex.EncodeToString(d.h.Sum(nil)) }
+
+// digestFile hashes an existing file, used for parts that were skipped.
+func digestFile(path string) (*fileDigest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := newFileDigest()
+	if _, err := io.Copy(d, f); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// existingRows counts the data rows of a file left in place by IfExistsSkip.
+func existingRows(path string, delim rune, withHeader bool) (int64, error) {
+	if withHeader {
+		return CountDataRows(path, delim)
+	}
+	return countRecords(path, delim)
+}
+
+// writeSplitManifest writes manifest.json into dir.
+func writeSplitManifest(dir, input string, start time.Time, rows int64, files []SplitFile) error {
+	m := SplitManifest{
+		Input:     input,
+		CreatedAt: start.UTC(),
+		Rows:      rows,
+		Files:     make([]SplitFile, len(files)),
+	}
+	for i, f := range files {
+		if rel, err := filepath.Rel(dir, f.Path); err == nil {
+			f.Path = filepath.ToSlash(rel)
+		}
+		m.Files[i] = f
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
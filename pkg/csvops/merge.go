@@ -5,21 +5,64 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// MergeMode selects how Merge lines up the columns of files whose headers
+// differ.
+type MergeMode string
+
+const (
+	// MergePositional writes rows as they are and keeps the first file's
+	// header; columns are matched by position only.
+	MergePositional MergeMode = "positional"
+	// MergeStrict fails before writing anything unless every header matches
+	// the first file's exactly.
+	MergeStrict MergeMode = "strict"
+	// MergeAlign reorders each file's columns by name to the first file's
+	// header. Missing columns are left empty and extra ones dropped.
+	MergeAlign MergeMode = "align"
+	// MergeUnion writes the superset of all columns, in order of first
+	// appearance, leaving cells empty where a file lacks a column.
+	MergeUnion MergeMode = "union"
+)
+
 // MergeOptions configures a Merge operation.
 type MergeOptions struct {
-	// InputDir is walked for *.csv files (non-recursive). Ignored if InputFiles is set.
+	// InputDir is searched for *.csv files, merged in natural order. Ignored
+	// if InputFiles or Patterns is set.
 	InputDir string
+	// Recursive also searches InputDir's subdirectories.
+	Recursive bool
 	// InputFiles is an explicit list of files to merge in order. Takes precedence over InputDir.
 	InputFiles []string
+	// Patterns are glob patterns (see ExpandGlob), expanded in order after
+	// InputFiles; each pattern's matches are merged in natural order and a
+	// file matched twice is merged once.
+	Patterns   []string
 	Output     io.Writer
 	WithHeader bool
-	Delimiter  rune
+	// Mode defaults to MergePositional. The other modes match columns by
+	// header name and need WithHeader.
+	Mode MergeMode
+	// SourceColumns appends SourceFileColumn and SourceRowColumn to every
+	// row: the input path and the 1-based data row within that file.
+	SourceColumns bool
+	// Delimiter is used for the output, and for the inputs unless
+	// DetectDialect is set.
+	Delimiter rune
+	// DetectDialect sniffs each input's delimiter and encoding (see
+	// DetectDialect), so files mixing commas and semicolons or UTF-16 and
+	// Latin-1 can be merged. The output is always UTF-8.
+	DetectDialect bool
+	// KeyColumns turns on "latest file wins" deduplication: of the rows
+	// sharing the same values in these columns, only the last one — from the
+	// latest file in merge order — is written. Needs WithHeader; every input
+	// is read twice, and one entry per distinct key is kept in memory.
+	KeyColumns []string
 	// SkipErrors controls behavior when a file fails mid-read: true skips and records
 	// a warning, false returns the error. Default false.
 	SkipErrors bool
@@ -28,14 +71,55 @@ type MergeOptions struct {
 	Progress Progress
 }
 
+// Lineage columns added by MergeOptions.SourceColumns.
+const (
+	SourceFileColumn = "_source_file"
+	SourceRowColumn  = "_source_row"
+)
+
+// MergeFileReport describes how one input file's columns mapped onto the
+// output header.
+type MergeFileReport struct {
+	Path string `json:"path"`
+	Rows int64  `json:"rows"`
+	// Added lists columns beyond the first file's header: appended to the
+	// output in union mode, dropped in align mode.
+	Added []string `json:"added,omitempty"`
+	// Missing lists output columns the file lacks. Align and union modes
+	// leave them empty in its rows; positional mode copies cells by position
+	// whatever their names, so there it only flags the mismatch.
+	Missing []string `json:"missing,omitempty"`
+	// Repeated lists names the file's header has more than once. Align and
+	// union modes match columns by name, so only the first column of each
+	// name is merged and the others are dropped.
+	Repeated []string `json:"repeated,omitempty"`
+	// Reordered is set when columns the file shares with the output header
+	// sit at different positions, which positional mode copies misaligned.
+	Reordered bool `json:"reordered,omitempty"`
+	// Delimiter and Encoding are the dialect the file was read with.
+	Delimiter string `json:"delimiter"`
+	Encoding  string `json:"encoding"`
+	// Superseded counts rows dropped because a later row has the same key.
+	Superseded int64 `json:"superseded,omitempty"`
+}
+
+// Changed reports whether the file's columns differ from the output header.
+func (r MergeFileReport) Changed() bool {
+	return len(r.Added) > 0 || len(r.Missing) > 0 || len(r.Repeated) > 0 || r.Reordered
+}
+
 // MergeResult is returned from Merge.
 type MergeResult struct {
-	FilesProcessed int
-	RowsWritten    int64
+	FilesProcessed int               `json:"files_processed"`
+	RowsWritten    int64             `json:"rows_written"`
+	Duplicates     int64             `json:"duplicates"`        // rows dropped by KeyColumns
+	Columns        []string          `json:"columns,omitempty"` // the output header
+	Files          []MergeFileReport `json:"files"`
 }
 
-// Merge streams one CSV at a time into opts.Output, writing the header from the
-// first file only when WithHeader is set.
+// Merge streams one CSV at a time into opts.Output, writing a single header
+// when WithHeader is set. With headers, every file's header is read first so
+// that Mode can check or line up the columns before any row is written.
 func Merge(ctx context.Context, opts MergeOptions) (MergeResult, error) {
 	var res MergeResult
 
@@ -45,55 +129,154 @@ func Merge(ctx context.Context, opts MergeOptions) (MergeResult, error) {
 	if opts.Delimiter == 0 {
 		opts.Delimiter = ','
 	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = MergePositional
+	case MergePositional:
+	case MergeStrict, MergeAlign, MergeUnion:
+		if !opts.WithHeader {
+			return res, fmt.Errorf("merge mode %q needs headers", opts.Mode)
+		}
+	default:
+		return res, fmt.Errorf("unknown merge mode %q (want positional, strict, align or union)", opts.Mode)
+	}
+	if len(opts.KeyColumns) > 0 && !opts.WithHeader {
+		return res, fmt.Errorf("key columns need headers")
+	}
+
+	paths, err := listInputs(opts.InputDir, opts.Recursive, opts.InputFiles, opts.Patterns)
+	if err != nil {
+		return res, err
+	}
+	if len(paths) == 0 {
+		return res, nil
+	}
 
-	files := opts.InputFiles
-	if len(files) == 0 {
-		if opts.InputDir == "" {
-			return res, fmt.Errorf("either InputDir or InputFiles is required")
+	skip := func(path string, err error) error {
+		if opts.SkipErrors {
+			if opts.OnWarn != nil {
+				opts.OnWarn(path, err)
+			}
+			return nil
 		}
-		entries, err := os.ReadDir(opts.InputDir)
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	// Pre-scan dialects and headers. Empty files keep a nil header.
+	var files []*mergeFile
+	for _, path := range paths {
+		mf, err := prepareMergeFile(path, opts)
 		if err != nil {
-			return res, fmt.Errorf("read input dir: %w", err)
+			if err := skip(path, err); err != nil {
+				return res, err
+			}
+			continue
 		}
-		for _, e := range entries {
-			if e.IsDir() {
+		files = append(files, mf)
+	}
+	headers := make([][]string, len(files))
+	for i, mf := range files {
+		headers[i] = mf.header
+	}
+	if opts.WithHeader {
+		res.Columns = mergeColumns(headers, opts.Mode)
+		if opts.SourceColumns && res.Columns != nil {
+			res.Columns = append(res.Columns, SourceFileColumn, SourceRowColumn)
+		}
+	}
+
+	// The first non-empty header is the reference for strict mode and for
+	// MergeFileReport.Added.
+	ref := slices.IndexFunc(headers, func(h []string) bool { return h != nil })
+	var first []string
+	if ref >= 0 {
+		first = headers[ref]
+	}
+	if opts.Mode == MergeStrict {
+		for i, h := range headers {
+			if h == nil || slices.Equal(h, first) {
 				continue
 			}
-			if strings.HasSuffix(strings.ToLower(e.Name()), ".csv") {
-				files = append(files, filepath.Join(opts.InputDir, e.Name()))
-			}
+			return res, fmt.Errorf("%s: header does not match %s (added: %s; missing: %s; or the order differs)",
+				filepath.Base(files[i].path), filepath.Base(files[ref].path), listOrNone(notIn(h, first)), listOrNone(notIn(first, h)))
 		}
-		sort.Strings(files)
 	}
 
-	if len(files) == 0 {
-		return res, nil
+	steps := int64(len(files))
+	var done int64
+	var latest map[string]rowPos
+	if len(opts.KeyColumns) > 0 {
+		steps *= 2
+		latest = make(map[string]rowPos)
+		kept := files[:0]
+		for i, mf := range files {
+			if err := ctx.Err(); err != nil {
+				return res, err
+			}
+			err := mf.indexKeys(int32(i), opts.KeyColumns, latest)
+			done++
+			safeProgress(opts.Progress, done, steps)
+			if err != nil {
+				if err := skip(mf.path, err); err != nil {
+					return res, err
+				}
+				continue
+			}
+			kept = append(kept, mf)
+		}
+		files = kept
 	}
 
 	writer := csv.NewWriter(opts.Output)
 	writer.Comma = opts.Delimiter
 	defer writer.Flush()
 
-	writtenHeader := false
+	if res.Columns != nil {
+		if err := writer.Write(res.Columns); err != nil {
+			return res, fmt.Errorf("write header: %w", err)
+		}
+	}
 
-	for i, path := range files {
+	for _, mf := range files {
 		if err := ctx.Err(); err != nil {
 			return res, err
 		}
-		n, err := mergeOneFile(path, writer, opts.WithHeader, &writtenHeader, opts.Delimiter)
-		if err != nil {
-			if opts.SkipErrors {
-				if opts.OnWarn != nil {
-					opts.OnWarn(path, err)
+		report := MergeFileReport{Path: mf.path, Delimiter: string(mf.dialect.Delimiter), Encoding: mf.dialect.Encoding}
+		var proj []int
+		if h := mf.header; h != nil {
+			cols := res.Columns
+			if opts.SourceColumns {
+				cols = cols[:len(cols)-2]
+			}
+			report.Added, report.Missing = notIn(h, first), notIn(cols, h)
+			for j, c := range h {
+				if k := slices.Index(cols, c); k >= 0 && k != j && slices.Index(h, c) == j {
+					report.Reordered = true
+					break
 				}
-				safeProgress(opts.Progress, int64(i+1), int64(len(files)))
-				continue
 			}
-			return res, fmt.Errorf("%s: %w", filepath.Base(path), err)
+			if opts.Mode == MergeAlign || opts.Mode == MergeUnion {
+				report.Repeated = repeatedNames(h)
+			}
+			if opts.Mode != MergePositional && !slices.Equal(h, cols) {
+				proj = projectColumns(h, cols)
+			}
 		}
+		n, dropped, err := mf.copyRows(writer, opts.WithHeader, proj, opts.SourceColumns, latest)
+		done++
+		safeProgress(opts.Progress, done, steps)
+		if err != nil {
+			if err := skip(mf.path, err); err != nil {
+				return res, err
+			}
+			continue
+		}
+		report.Rows = n
+		report.Superseded = dropped
+		res.Files = append(res.Files, report)
 		res.RowsWritten += n
+		res.Duplicates += dropped
 		res.FilesProcessed++
-		safeProgress(opts.Progress, int64(i+1), int64(len(files)))
 	}
 
 	writer.Flush()
@@ -103,43 +286,225 @@ func Merge(ctx context.Context, opts MergeOptions) (MergeResult, error) {
 	return res, nil
 }
 
-func mergeOneFile(path string, writer *csv.Writer, withHeader bool, writtenHeader *bool, delim rune) (int64, error) {
-	f, err := os.Open(path)
+// mergeFile is one input with the dialect and header found by the pre-scan.
+type mergeFile struct {
+	path    string
+	index   int32 // position in merge order, for rowPos
+	dialect Dialect
+	header  []string
+	keyIdx  []int
+}
+
+// rowPos locates a data row: the file's merge position and its 1-based row.
+type rowPos struct {
+	file int32
+	row  int64
+}
+
+func prepareMergeFile(path string, opts MergeOptions) (*mergeFile, error) {
+	mf := &mergeFile{path: path, dialect: Dialect{Delimiter: opts.Delimiter, Encoding: EncodingUTF8}}
+	if opts.DetectDialect {
+		d, err := DetectDialect(path)
+		if err != nil {
+			return nil, err
+		}
+		mf.dialect = d
+	}
+	if !opts.WithHeader {
+		return mf, nil
+	}
+	r, c, err := mf.open()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	defer f.Close()
+	defer c.Close()
+	h, err := r.Read()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	mf.header = h
+	return mf, nil
+}
 
+func (mf *mergeFile) open() (*csv.Reader, io.Closer, error) {
+	f, err := openDecoded(mf.path, mf.dialect.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
 	r := csv.NewReader(f)
-	r.Comma = delim
+	r.Comma = mf.dialect.Delimiter
 	r.FieldsPerRecord = -1
+	return r, f, nil
+}
+
+// indexKeys records in latest the position of every key's last row in this
+// file, overwriting those of earlier files. latest is only updated once the
+// whole file has been read, so a file that fails part way leaves the earlier
+// files' rows in place.
+func (mf *mergeFile) indexKeys(index int32, keys []string, latest map[string]rowPos) error {
+	mf.index = index
+	if mf.header == nil {
+		return nil
+	}
+	idx, err := resolveKeyIndexes(mf.header, keys, true)
+	if err != nil {
+		return err
+	}
+	mf.keyIdx = idx
+
+	r, c, err := mf.open()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if _, err := r.Read(); err != nil {
+		return err
+	}
+	last := make(map[string]int64)
+	for n := int64(1); ; n++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		last[rowKey(row, idx, true)] = n
+	}
+	for k, n := range last {
+		latest[k] = rowPos{index, n}
+	}
+	return nil
+}
+
+// mergeColumns picks the output header: the union of all headers in order of
+// first appearance for MergeUnion, the first non-empty header otherwise. The
+// by-name modes keep one column per name.
+func mergeColumns(headers [][]string, mode MergeMode) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, h := range headers {
+		if h == nil {
+			continue
+		}
+		if mode != MergeAlign && mode != MergeUnion {
+			return append([]string{}, h...)
+		}
+		for _, c := range h {
+			if !seen[c] {
+				seen[c] = true
+				out = append(out, c)
+			}
+		}
+		if mode != MergeUnion {
+			return out
+		}
+	}
+	return out
+}
+
+// repeatedNames lists the names that appear more than once in h, in order.
+func repeatedNames(h []string) []string {
+	var out []string
+	seen := make(map[string]int, len(h))
+	for _, c := range h {
+		if seen[c]++; seen[c] == 2 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// notIn returns the entries of list that are not in ref.
+func notIn(list, ref []string) []string {
+	var out []string
+	for _, c := range list {
+		if !slices.Contains(ref, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// projectColumns maps each output column to its index in h, or -1. Repeated
+// names use their first occurrence.
+func projectColumns(h, out []string) []int {
+	pos := make(map[string]int, len(h))
+	for i, c := range h {
+		if _, dup := pos[c]; !dup {
+			pos[c] = i
+		}
+	}
+	proj := make([]int, len(out))
+	for i, c := range out {
+		proj[i] = -1
+		if j, ok := pos[c]; ok {
+			proj[i] = j
+		}
+	}
+	return proj
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}
+
+// copyRows writes the file's rows to writer, skipping its header row when
+// skipHeader is set. A non-nil proj rearranges each row: output column i
+// takes source field proj[i], or stays empty when that is -1 or out of range.
+// source appends the lineage columns. With latest set, rows whose key has a
+// later occurrence are dropped and counted.
+func (mf *mergeFile) copyRows(writer *csv.Writer, skipHeader bool, proj []int, source bool, latest map[string]rowPos) (written, dropped int64, err error) {
+	r, c, err := mf.open()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer c.Close()
 
 	first := true
-	var count int64
+	var n int64 // data rows read
+	var out []string
+	if proj != nil {
+		out = make([]string, len(proj))
+	}
 	for {
 		row, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return count, err
+			return written, dropped, err
 		}
 		if first {
 			first = false
-			if withHeader {
-				if !*writtenHeader {
-					if err := writer.Write(row); err != nil {
-						return count, err
-					}
-					*writtenHeader = true
-				}
+			if skipHeader {
 				continue
 			}
 		}
+		n++
+		if latest != nil && mf.keyIdx != nil && latest[rowKey(row, mf.keyIdx, true)] != (rowPos{mf.index, n}) {
+			dropped++
+			continue
+		}
+		if proj != nil {
+			for i, j := range proj {
+				out[i] = ""
+				if j >= 0 && j < len(row) {
+					out[i] = row[j]
+				}
+			}
+			row = out
+		}
+		if source {
+			row = append(row, mf.path, strconv.FormatInt(n, 10))
+		}
 		if err := writer.Write(row); err != nil {
-			return count, err
+			return written, dropped, err
 		}
-		count++
+		written++
 	}
-	return count, nil
+	return written, dropped, nil
 }
@@ -3,6 +3,9 @@ package csvops
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -124,3 +127,244 @@ func TestMerge_SkipErrorsOnMissingFile(t *testing.T) {
 		t.Errorf("warnings = %v", warned)
 	}
 }
+
+func TestMerge_Modes(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	c := filepath.Join(dir, "c.csv")
+	writeCSV(t, a, "id,name\n1,a\n")
+	writeCSV(t, b, "name,id,email\nb,2,b@x\n")
+	writeCSV(t, c, "id\n3\n")
+
+	for _, tc := range []struct {
+		mode MergeMode
+		want string
+	}{
+		{MergePositional, "id,name\n1,a\nb,2,b@x\n3\n"},
+		{MergeAlign, "id,name\n1,a\n2,b\n3,\n"},
+		{MergeUnion, "id,name,email\n1,a,\n2,b,b@x\n3,,\n"},
+	} {
+		var buf bytes.Buffer
+		res, err := Merge(context.Background(), MergeOptions{
+			InputFiles: []string{a, b, c},
+			Output:     &buf,
+			WithHeader: true,
+			Mode:       tc.mode,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.mode, err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", tc.mode, buf.String(), tc.want)
+		}
+		if res.RowsWritten != 3 || len(res.Files) != 3 {
+			t.Errorf("%s: res = %+v", tc.mode, res)
+		}
+		if tc.mode == MergeUnion {
+			if f := res.Files[1]; strings.Join(f.Added, ",") != "email" || len(f.Missing) != 0 || !f.Reordered {
+				t.Errorf("b report = %+v", f)
+			}
+			if f := res.Files[2]; strings.Join(f.Missing, ",") != "name,email" {
+				t.Errorf("c report = %+v", f)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	_, err := Merge(context.Background(), MergeOptions{
+		InputFiles: []string{a, b},
+		Output:     &buf,
+		WithHeader: true,
+		Mode:       MergeStrict,
+	})
+	if err == nil || !strings.Contains(err.Error(), "added: email") {
+		t.Errorf("strict err = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("strict mode wrote output before failing: %q", buf.String())
+	}
+
+	if _, err := Merge(context.Background(), MergeOptions{InputFiles: []string{a}, Output: &buf, Mode: MergeAlign}); err == nil {
+		t.Error("align without headers should fail")
+	}
+}
+
+func TestMerge_RecursiveNaturalOrderWithSourceColumns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeCSV(t, filepath.Join(dir, "part_10.csv"), "id\n10\n")
+	writeCSV(t, filepath.Join(dir, "part_9.csv"), "id\n9\n")
+	writeCSV(t, filepath.Join(dir, "sub", "part_1.csv"), "id\n1\n2\n")
+
+	var buf bytes.Buffer
+	res, err := Merge(context.Background(), MergeOptions{
+		InputDir:      dir,
+		Recursive:     true,
+		Output:        &buf,
+		WithHeader:    true,
+		SourceColumns: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesProcessed != 3 {
+		t.Fatalf("FilesProcessed = %d", res.FilesProcessed)
+	}
+	p9, p10, p1 := filepath.Join(dir, "part_9.csv"), filepath.Join(dir, "part_10.csv"), filepath.Join(dir, "sub", "part_1.csv")
+	want := "id,_source_file,_source_row\n" +
+		"9," + p9 + ",1\n" +
+		"10," + p10 + ",1\n" +
+		"1," + p1 + ",1\n" +
+		"2," + p1 + ",2\n"
+	if buf.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestMerge_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCSV(t, filepath.Join(dir, "a", "2025-02.csv"), "id\n2\n")
+	writeCSV(t, filepath.Join(dir, "b", "2025-01.csv"), "id\n1\n")
+	writeCSV(t, filepath.Join(dir, "b", "2024-12.csv"), "id\n0\n")
+
+	var buf bytes.Buffer
+	_, err := Merge(context.Background(), MergeOptions{
+		InputFiles: []string{filepath.Join(dir, "b", "2025-01.csv")},
+		Patterns:   []string{filepath.Join(dir, "**", "2025-*.csv")},
+		Output:     &buf,
+		WithHeader: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id\n1\n2\n" {
+		t.Errorf("output:\n%s", buf.String())
+	}
+}
+
+func TestMerge_DetectDialectAndLatestKeyWins(t *testing.T) {
+	dir := t.TempDir()
+	day1 := filepath.Join(dir, "day_1.csv")
+	day2 := filepath.Join(dir, "day_2.csv")
+	day10 := filepath.Join(dir, "day_10.csv")
+	writeCSV(t, day1, "id,name\n1,Ann\n2,Bob\n3,Cy\n")
+	writeCSV(t, day2, "id;name\n2;Bobby\n4;D\xe9\n4;Dee\n") // Latin-1, repeated key
+	writeCSV(t, day10, "\xff\xfei\x00d\x00\t\x00n\x00a\x00m\x00e\x00\n\x001\x00\t\x00A\x00\n\x00")
+
+	var buf bytes.Buffer
+	res, err := Merge(context.Background(), MergeOptions{
+		InputDir:      dir,
+		Output:        &buf,
+		WithHeader:    true,
+		DetectDialect: true,
+		KeyColumns:    []string{"id"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name\n3,Cy\n2,Bobby\n4,Dee\n1,A\n"
+	if buf.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if res.RowsWritten != 4 || res.Duplicates != 3 {
+		t.Errorf("RowsWritten = %d, Duplicates = %d", res.RowsWritten, res.Duplicates)
+	}
+	got := make([]string, len(res.Files))
+	for i, f := range res.Files {
+		got[i] = fmt.Sprintf("%s %s %s %d", filepath.Base(f.Path), f.Delimiter, f.Encoding, f.Superseded)
+	}
+	wantFiles := []string{"day_1.csv , utf-8 2", "day_2.csv ; latin-1 1", "day_10.csv \t utf-16le 0"}
+	if strings.Join(got, "|") != strings.Join(wantFiles, "|") {
+		t.Errorf("files = %q", got)
+	}
+}
+
+func TestMerge_RepeatedHeaderNames(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	writeCSV(t, a, "id,name,id\n1,a,9\n")
+	writeCSV(t, b, "name,name\nb,c\n")
+
+	for _, tc := range []struct {
+		mode MergeMode
+		want string
+	}{
+		{MergeAlign, "id,name\n1,a\n,b\n"},
+		{MergeUnion, "id,name\n1,a\n,b\n"},
+		{MergePositional, "id,name,id\n1,a,9\nb,c\n"},
+	} {
+		var buf bytes.Buffer
+		res, err := Merge(context.Background(), MergeOptions{
+			InputFiles: []string{a, b},
+			Output:     &buf,
+			WithHeader: true,
+			Mode:       tc.mode,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.mode, err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", tc.mode, buf.String(), tc.want)
+		}
+		var got []string
+		for _, f := range res.Files {
+			got = append(got, fmt.Sprintf("%v %v %t", f.Repeated, f.Missing, f.Reordered))
+		}
+		want := "[id] [] false|[name] [id] true"
+		if tc.mode == MergePositional {
+			want = "[] [] false|[] [id id] true"
+		}
+		if strings.Join(got, "|") != want {
+			t.Errorf("%s reports = %q, want %q", tc.mode, got, want)
+		}
+	}
+}
+
+func TestMerge_KeySkipsMalformedLaterFile(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	writeCSV(t, a, "id,name\n1,Ann\n2,Bob\n")
+	writeCSV(t, b, "id,name\n1,Anna\n2,\"Bo\"b\n") // fails after indexing id 1
+
+	var warned []string
+	var buf bytes.Buffer
+	res, err := Merge(context.Background(), MergeOptions{
+		InputFiles: []string{a, b},
+		Output:     &buf,
+		WithHeader: true,
+		KeyColumns: []string{"id"},
+		SkipErrors: true,
+		OnWarn: func(file string, e error) {
+			warned = append(warned, filepath.Base(file))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "id,name\n1,Ann\n2,Bob\n"; buf.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if res.RowsWritten != 2 || res.Duplicates != 0 || res.FilesProcessed != 1 {
+		t.Errorf("RowsWritten = %d, Duplicates = %d, FilesProcessed = %d", res.RowsWritten, res.Duplicates, res.FilesProcessed)
+	}
+	if len(warned) != 1 || warned[0] != "b.csv" {
+		t.Errorf("warnings = %v", warned)
+	}
+}
+
+func TestMerge_KeyNeedsHeader(t *testing.T) {
+	_, err := Merge(context.Background(), MergeOptions{InputFiles: []string{"x.csv"}, Output: io.Discard, KeyColumns: []string{"id"}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
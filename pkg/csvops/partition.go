@@ -0,0 +1,331 @@
+package csvops
+
+import (
+	"container/list"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultMaxOpenFiles bounds the partition writer LRU when
+// SplitOptions.MaxOpenFiles is unset.
+const defaultMaxOpenFiles = 64
+
+// emptyPartitionValue names the partition for empty cells, mirroring Hive's
+// __HIVE_DEFAULT_PARTITION__ convention.
+const emptyPartitionValue = "__empty__"
+
+// PartitionInfo describes one output file written in partition mode.
+type PartitionInfo struct {
+	Values []string `json:"values"` // raw column values, in PartitionBy order
+	// MergedValues lists the other value combinations written to the same
+	// file because they sanitize to the same path ("a/b" and "a_b").
+	MergedValues [][]string `json:"merged_values,omitempty"`
+	Path         string     `json:"path"`
+	// Rows counts the rows written, or for a skipped file the rows already
+	// in it, so it always agrees with the file's size and digest.
+	Rows int64 `json:"rows"`
+}
+
+// partitionState tracks one partition file across LRU evictions.
+type partitionState struct {
+	info    *PartitionInfo
+	file    int // index into the SplitResult.Files being built
+	digest  *fileDigest
+	skipped bool
+	opened  bool // created in this run; reopen for append after eviction
+}
+
+// partitionWriter is an open partition file tracked by the LRU.
+type partitionWriter struct {
+	key  string
+	f    *os.File
+	w    *csv.Writer
+	elem *list.Element
+}
+
+// partitionSet owns the open partition writers and evicts the least recently
+// used one once more than max files would be open.
+type partitionSet struct {
+	opts   SplitOptions
+	header []string
+	keyIdx []int
+	namer  *splitNamer
+
+	open  map[string]*partitionWriter // by path
+	lru   *list.List                  // front = most recently used
+	parts map[string]*partitionState  // by path
+	byKey map[string]*partitionState  // by raw values
+	files []SplitFile
+}
+
+// writer returns the writer for the partition identified by values, or nil
+// when the partition's file already existed and IfExists is skip. Values
+// that sanitize to the same path share one file.
+func (s *partitionSet) writer(values []string) (*csv.Writer, *partitionState, error) {
+	key := strings.Join(values, "\x00")
+	st, ok := s.byKey[key]
+	if !ok {
+		path := partitionPath(s.opts.OutputDir, s.header, s.keyIdx, values, s.opts.Hive, s.namer, len(s.parts)+1)
+		if st, ok = s.parts[path]; ok {
+			st.info.MergedValues = append(st.info.MergedValues, append([]string(nil), values...))
+		} else {
+			st = &partitionState{
+				info:   &PartitionInfo{Values: append([]string(nil), values...), Path: path},
+				file:   len(s.files),
+				digest: newFileDigest(),
+			}
+			s.parts[path] = st
+			s.files = append(s.files, SplitFile{Path: path, Values: st.info.Values})
+			safeProgress(s.opts.PartProgress, int64(len(s.files)), 0)
+			skip, err := checkIfExists(path, s.opts.IfExists)
+			if err != nil {
+				return nil, nil, err
+			}
+			st.skipped = skip
+		}
+		s.byKey[key] = st
+	}
+	if st.skipped {
+		return nil, st, nil
+	}
+	path := st.info.Path
+	if pw, ok := s.open[path]; ok {
+		s.lru.MoveToFront(pw.elem)
+		return pw.w, st, nil
+	}
+
+	// A partition opened earlier in this run was evicted: reopen for append.
+	// Otherwise start fresh so stale files from a previous run are replaced.
+	for len(s.open) >= s.opts.MaxOpenFiles {
+		if err := s.evict(s.lru.Back().Value.(*partitionWriter)); err != nil {
+			return nil, nil, err
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if st.opened {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("create partition dir: %w", err)
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	w := csv.NewWriter(io.MultiWriter(f, st.digest))
+	w.Comma = s.opts.Delimiter
+	if !st.opened && s.opts.WithHeader && len(s.header) > 0 {
+		if err := w.Write(s.header); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	st.opened = true
+
+	pw := &partitionWriter{key: path, f: f, w: w}
+	pw.elem = s.lru.PushFront(pw)
+	s.open[path] = pw
+	return w, st, nil
+}
+
+func (s *partitionSet) evict(pw *partitionWriter) error {
+	s.lru.Remove(pw.elem)
+	delete(s.open, pw.key)
+	pw.w.Flush()
+	if err := pw.w.Error(); err != nil {
+		pw.f.Close()
+		return fmt.Errorf("write %s: %w", pw.f.Name(), err)
+	}
+	if err := pw.f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", pw.f.Name(), err)
+	}
+	return nil
+}
+
+func (s *partitionSet) closeAll() error {
+	var first error
+	for s.lru.Len() > 0 {
+		if err := s.evict(s.lru.Back().Value.(*partitionWriter)); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// finish closes every writer and fills in the partition manifest and the
+// per-file sizes and digests.
+func (s *partitionSet) finish(res *SplitResult) error {
+	if err := s.closeAll(); err != nil {
+		return err
+	}
+	res.Partitions = make([]PartitionInfo, 0, len(s.parts))
+	for _, st := range s.parts {
+		sf := &s.files[st.file]
+		d := st.digest
+		if st.skipped {
+			var err error
+			if d, err = digestFile(sf.Path); err != nil {
+				return err
+			}
+			if st.info.Rows, err = existingRows(sf.Path, s.opts.Delimiter, s.opts.WithHeader); err != nil {
+				return err
+			}
+			sf.Skipped = true
+		}
+		sf.Rows = st.info.Rows
+		sf.MergedValues = st.info.MergedValues
+		sf.Bytes, sf.SHA256 = d.n, d.sum()
+		res.Partitions = append(res.Partitions, *st.info)
+	}
+	sort.Slice(res.Partitions, func(i, j int) bool { return res.Partitions[i].Path < res.Partitions[j].Path })
+	res.Files = s.files
+	res.FilesCreated = len(s.files)
+	return nil
+}
+
+// splitPartitioned implements Split when opts.PartitionBy is set. Split has
+// already checked WithHeader, so the first row is the header, repeated in
+// each partition file.
+func splitPartitioned(ctx context.Context, opts SplitOptions, total int64, namer *splitNamer) (SplitResult, error) {
+	var res SplitResult
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return res, fmt.Errorf("read header: %w", err)
+	}
+	keyIdx, err := resolveKeyIndexes(header, opts.PartitionBy, true)
+	if err != nil {
+		return res, err
+	}
+
+	if opts.MaxOpenFiles <= 0 {
+		opts.MaxOpenFiles = defaultMaxOpenFiles
+	}
+	set := &partitionSet{
+		opts:   opts,
+		header: header,
+		keyIdx: keyIdx,
+		namer:  namer,
+		open:   map[string]*partitionWriter{},
+		lru:    list.New(),
+		parts:  map[string]*partitionState{},
+		byKey:  map[string]*partitionState{},
+	}
+	defer set.closeAll()
+
+	values := make([]string, len(keyIdx))
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("read row: %w", err)
+		}
+		for i, idx := range keyIdx {
+			values[i] = ""
+			if idx < len(row) {
+				values[i] = row[idx]
+			}
+		}
+		w, st, err := set.writer(values)
+		if err != nil {
+			return res, err
+		}
+		if w != nil {
+			if err := w.Write(row); err != nil {
+				return res, fmt.Errorf("write %s: %w", st.info.Path, err)
+			}
+			st.info.Rows++
+		}
+		res.RowsProcessed++
+		safeProgress(opts.Progress, res.RowsProcessed, total)
+	}
+
+	if err := set.finish(&res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// partitionPath builds the output path for a set of partition values.
+// Hive layout nests col=value directories and ends in data.csv; the plain
+// layout nests value directories and names the file after the last value.
+// A name template, when set, replaces the leaf file name in either layout.
+func partitionPath(dir string, header []string, keyIdx []int, values []string, hive bool, namer *splitNamer, n int) string {
+	parts := make([]string, 0, len(values)+2)
+	parts = append(parts, dir)
+	for i, v := range values {
+		v = SanitizeFileName(v)
+		if hive {
+			parts = append(parts, SanitizeFileName(header[keyIdx[i]])+"="+v)
+			continue
+		}
+		if i == len(values)-1 {
+			break
+		}
+		parts = append(parts, v)
+	}
+	switch {
+	case namer.tmpl != "" && hive:
+		parts = append(parts, namer.name(n, values))
+	case namer.tmpl != "":
+		parts = append(parts, namer.name(n, values[len(values)-1:]))
+	case hive:
+		parts = append(parts, "data.csv")
+	default:
+		parts = append(parts, SanitizeFileName(values[len(values)-1])+".csv")
+	}
+	return filepath.Join(parts...)
+}
+
+// SanitizeFileName turns an arbitrary cell value into a single safe path
+// element: separators, reserved and control characters become '_', leading
+// and trailing dots and spaces are trimmed, and the result is capped at 100
+// bytes. Empty values map to "__empty__".
+func SanitizeFileName(v string) string {
+	var b strings.Builder
+	for _, r := range v {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' ||
+			r == '"' || r == '<' || r == '>' || r == '|' || r == '=':
+			b.WriteRune('_')
+		case unicode.IsControl(r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	s := strings.Trim(b.String(), ". ")
+	if len(s) > 100 {
+		s = s[:100]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	if s == "" {
+		return emptyPartitionValue
+	}
+	return s
+}
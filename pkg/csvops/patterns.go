@@ -0,0 +1,202 @@
+package csvops
+
+import (
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PatternCount is how many non-empty values of a column share a shape or
+// semantic type, and what fraction of the non-empty values that is.
+type PatternCount struct {
+	Pattern string  `json:"pattern"`
+	Count   int64   `json:"count"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// SemanticType is a recognized kind of value such as an email address.
+type SemanticType string
+
+const (
+	SemanticEmail    SemanticType = "email"
+	SemanticURL      SemanticType = "url"
+	SemanticUUID     SemanticType = "uuid"
+	SemanticIPv4     SemanticType = "ipv4"
+	SemanticIPv6     SemanticType = "ipv6"
+	SemanticISODate  SemanticType = "iso-date"
+	SemanticCurrency SemanticType = "currency"
+	SemanticPhone    SemanticType = "phone"
+)
+
+// maxShapes bounds the distinct shapes tracked per column; later shapes are
+// counted under OtherPattern. Shapes longer than maxShapeRunes are cut.
+const (
+	maxShapes     = 1000
+	maxShapeRunes = 32
+	// OtherPattern collects values whose shape arrived after a column already
+	// had maxShapes distinct shapes.
+	OtherPattern = "(other)"
+)
+
+var (
+	emailRe    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`)
+	uuidRe     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	currencyRe = regexp.MustCompile(`^[-+]?(?:[$€£¥₹]\s?[\d,]*\d(?:\.\d+)?|[\d,]*\d(?:\.\d+)?\s?(?:USD|EUR|GBP|JPY|EGP|INR|CAD|AUD|CHF))$`)
+	phoneRe    = regexp.MustCompile(`^\+?[\d\s().-]+$`)
+)
+
+// ValueShape reduces v to its shape signature: letters become A, digits 9
+// and everything else is kept, so "(555) 123-4567" has shape
+// "(999) 999-9999" and "EGY-2024" has shape "AAA-9999".
+func ValueShape(v string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range v {
+		if n == maxShapeRunes {
+			b.WriteString("…")
+			break
+		}
+		switch {
+		case unicode.IsLetter(r):
+			b.WriteByte('A')
+		case unicode.IsDigit(r):
+			b.WriteByte('9')
+		default:
+			b.WriteRune(r)
+		}
+		n++
+	}
+	return b.String()
+}
+
+// DetectSemanticType returns the first semantic type v matches, or "" when
+// it matches none. Values are checked from most to least specific.
+func DetectSemanticType(v string) SemanticType {
+	switch {
+	case uuidRe.MatchString(v):
+		return SemanticUUID
+	case emailRe.MatchString(v):
+		return SemanticEmail
+	case isURL(v):
+		return SemanticURL
+	}
+	if addr, err := netip.ParseAddr(v); err == nil {
+		if addr.Is4() {
+			return SemanticIPv4
+		}
+		return SemanticIPv6
+	}
+	switch {
+	case isISODate(v):
+		return SemanticISODate
+	case currencyRe.MatchString(v):
+		return SemanticCurrency
+	case isPhone(v):
+		return SemanticPhone
+	}
+	return ""
+}
+
+func isURL(v string) bool {
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp":
+		return true
+	}
+	return false
+}
+
+func isISODate(v string) bool {
+	for _, l := range []string{"2006-01-02", time.RFC3339Nano, "2006-01-02T15:04:05"} {
+		if _, err := time.Parse(l, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isPhone accepts 7 to 15 digits with optional separators. Values without a
+// leading + need a separator and must not read as a plain number, so IDs
+// like 1234567890 and amounts like -1234567 or 1234567.89 are left out.
+func isPhone(v string) bool {
+	if !phoneRe.MatchString(v) {
+		return false
+	}
+	if v[0] != '+' {
+		if v[0] != '(' && (v[0] < '0' || v[0] > '9') {
+			return false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return false
+		}
+	}
+	digits := 0
+	for _, r := range v {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return false
+	}
+	return v[0] == '+' || digits != len(v)
+}
+
+// patternAcc counts shapes and semantic types for one column.
+type patternAcc struct {
+	shapes   map[string]int64
+	semantic map[SemanticType]int64
+}
+
+func newPatternAcc() *patternAcc {
+	return &patternAcc{shapes: make(map[string]int64), semantic: make(map[SemanticType]int64)}
+}
+
+func (p *patternAcc) add(v string) {
+	shape := ValueShape(v)
+	if _, ok := p.shapes[shape]; !ok && len(p.shapes) >= maxShapes {
+		shape = OtherPattern
+	}
+	p.shapes[shape]++
+	if t := DetectSemanticType(v); t != "" {
+		p.semantic[t]++
+	}
+}
+
+// topPatterns returns the n largest counts as shares of total, ordered by
+// count then pattern.
+func topPatterns(counts map[string]int64, total int64, n int) []PatternCount {
+	out := make([]PatternCount, 0, len(counts))
+	for p, c := range counts {
+		out = append(out, PatternCount{Pattern: p, Count: c, Ratio: float64(c) / float64(total)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Pattern < out[j].Pattern
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func (p *patternAcc) result(nonEmpty int64, topN int) (shapes, semantic []PatternCount) {
+	if nonEmpty == 0 {
+		return nil, nil
+	}
+	sem := make(map[string]int64, len(p.semantic))
+	for t, c := range p.semantic {
+		sem[string(t)] = c
+	}
+	return topPatterns(p.shapes, nonEmpty, topN), topPatterns(sem, nonEmpty, 0)
+}
@@ -0,0 +1,90 @@
+package csvops
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValueShape(t *testing.T) {
+	cases := map[string]string{
+		"EGY-2024":              "AAA-9999",
+		"(555) 123-4567":        "(999) 999-9999",
+		"john.doe@x.io":         "AAAA.AAA@A.AA",
+		"Ünïcode 12":            "AAAAAAA 99",
+		strings.Repeat("a", 40): strings.Repeat("A", maxShapeRunes) + "…",
+	}
+	for in, want := range cases {
+		if got := ValueShape(in); got != want {
+			t.Errorf("ValueShape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDetectSemanticType(t *testing.T) {
+	cases := map[string]SemanticType{
+		"jane@example.com":                     SemanticEmail,
+		"https://example.com/a?b=c":            SemanticURL,
+		"123e4567-e89b-12d3-a456-426614174000": SemanticUUID,
+		"192.168.1.10":                         SemanticIPv4,
+		"2001:db8::1":                          SemanticIPv6,
+		"2024-03-15":                           SemanticISODate,
+		"2024-03-15T10:00:00Z":                 SemanticISODate,
+		"$1,234.50":                            SemanticCurrency,
+		"99.90 EUR":                            SemanticCurrency,
+		"(555) 123-4567":                       SemanticPhone,
+		"+201001234567":                        SemanticPhone,
+		"555.123.4567":                         SemanticPhone,
+		"1234567890":                           "", // bare digits look like an ID
+		"1234567.89":                           "", // plain numbers are not phones
+		"-1234567":                             "",
+		"-123-4567":                            "",
+		"hello world":                          "",
+		"example.com":                          "",
+	}
+	for in, want := range cases {
+		if got := DetectSemanticType(in); got != want {
+			t.Errorf("DetectSemanticType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStats_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("phone\n")
+	for i := 0; i < 92; i++ {
+		fmt.Fprintf(&b, "(555) 123-%04d\n", i)
+	}
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(&b, "555.123.%04d x%d\n", i, i)
+	}
+	b.WriteString("\n") // empty cells don't count towards ratios
+	writeCSV(t, in, b.String())
+
+	res, err := Stats(context.Background(), StatsOptions{Input: in, Patterns: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := res.Columns[0]
+	if len(col.Patterns) != 2 {
+		t.Fatalf("Patterns = %+v", col.Patterns)
+	}
+	if p := col.Patterns[0]; p.Pattern != "(999) 999-9999" || p.Count != 92 || p.Ratio != 0.92 {
+		t.Errorf("Patterns[0] = %+v, want (999) 999-9999 at 92%%", p)
+	}
+	if s := col.SemanticTypes; len(s) != 1 || s[0].Pattern != string(SemanticPhone) || s[0].Count != 92 {
+		t.Errorf("SemanticTypes = %+v, want phone x92", s)
+	}
+
+	plain, err := Stats(context.Background(), StatsOptions{Input: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Columns[0].Patterns != nil {
+		t.Error("Patterns set without StatsOptions.Patterns")
+	}
+}
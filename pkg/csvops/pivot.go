@@ -0,0 +1,331 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PivotAgg chooses how Pivot combines values that land in the same cell.
+type PivotAgg string
+
+const (
+	AggFirst PivotAgg = "first"
+	AggLast  PivotAgg = "last"
+	AggCount PivotAgg = "count"
+	AggSum   PivotAgg = "sum"
+	AggMin   PivotAgg = "min"
+	AggMax   PivotAgg = "max"
+	AggMean  PivotAgg = "mean"
+)
+
+// PivotOptions configures a Pivot operation.
+type PivotOptions struct {
+	Input  string
+	Output io.Writer
+	// RowKeys identify an output row; PivotColumn's distinct values become
+	// the output columns, in order of first appearance; ValueColumn fills
+	// the cells.
+	RowKeys     []string
+	PivotColumn string
+	ValueColumn string
+	// Agg combines values that share a row key and pivot value (default
+	// AggFirst). sum, min, max and mean need numbers; empty values are
+	// skipped.
+	Agg PivotAgg
+	// Fill is written where a row key has no value for a column.
+	Fill string
+	// MaxColumns guards against pivoting on a high-cardinality column
+	// (default 1000).
+	MaxColumns int
+	Delimiter  rune
+	Progress   Progress
+}
+
+// PivotResult is returned from Pivot.
+type PivotResult struct {
+	TotalRows   int64    `json:"total_rows"`
+	RowsWritten int64    `json:"rows_written"`
+	Columns     []string `json:"columns"` // the pivoted columns
+	// Collisions counts values combined by Agg into a cell that already had
+	// one.
+	Collisions int64 `json:"collisions"`
+	// Grouped is set when every row key's rows were contiguous, so rows were
+	// written as each group ended instead of being held until the end.
+	Grouped bool `json:"grouped"`
+}
+
+// Pivot reshapes long data to wide: one output row per distinct RowKeys
+// value, one column per distinct PivotColumn value. The first pass finds the
+// output columns and whether the input is grouped by row key; the second
+// aggregates and writes. Memory holds the distinct row keys, plus the cells
+// of every row when the input is not grouped.
+func Pivot(ctx context.Context, opts PivotOptions) (PivotResult, error) {
+	var res PivotResult
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	if len(opts.RowKeys) == 0 || opts.PivotColumn == "" || opts.ValueColumn == "" {
+		return res, fmt.Errorf("row keys, pivot column and value column are required")
+	}
+	switch opts.Agg {
+	case "":
+		opts.Agg = AggFirst
+	case AggFirst, AggLast, AggCount, AggSum, AggMin, AggMax, AggMean:
+	default:
+		return res, fmt.Errorf("unknown aggregation %q (want first, last, count, sum, min, max or mean)", opts.Agg)
+	}
+	if opts.MaxColumns <= 0 {
+		opts.MaxColumns = 1000
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	total, err := CountDataRows(opts.Input, opts.Delimiter)
+	if err != nil {
+		return res, err
+	}
+	res.TotalRows = total
+	var done int64
+	step := func() {
+		done++
+		safeProgress(opts.Progress, done, 2*total)
+	}
+
+	// Pass 1: output columns and grouping.
+	var (
+		header  []string
+		keyIdx  []int
+		pivIdx  int
+		valIdx  int
+		colPos  = make(map[string]int)
+		seen    = make(map[string]struct{})
+		lastKey string
+	)
+	res.Grouped = true
+	err = readRows(ctx, opts.Input, opts.Delimiter, func(h []string) error {
+		header = h
+		cols, err := resolveKeyIndexes(h, append(append([]string{}, opts.RowKeys...), opts.PivotColumn, opts.ValueColumn), true)
+		if err != nil {
+			return err
+		}
+		keyIdx, pivIdx, valIdx = cols[:len(opts.RowKeys)], cols[len(cols)-2], cols[len(cols)-1]
+		return nil
+	}, func(row []string) error {
+		step()
+		p := cell(row, pivIdx)
+		if _, ok := colPos[p]; !ok {
+			if len(colPos) == opts.MaxColumns {
+				return fmt.Errorf("pivot column %q has more than %d distinct values", opts.PivotColumn, opts.MaxColumns)
+			}
+			colPos[p] = len(res.Columns)
+			res.Columns = append(res.Columns, p)
+		}
+		k := rowKey(row, keyIdx, true)
+		if k != lastKey || len(seen) == 0 {
+			if _, ok := seen[k]; ok {
+				res.Grouped = false
+			}
+			seen[k] = struct{}{}
+			lastKey = k
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	seen = nil
+
+	writer := csv.NewWriter(opts.Output)
+	writer.Comma = opts.Delimiter
+	out := make([]string, len(keyIdx)+len(res.Columns))
+	for i, j := range keyIdx {
+		out[i] = header[j]
+	}
+	copy(out[len(keyIdx):], res.Columns)
+	if err := writer.Write(out); err != nil {
+		return res, fmt.Errorf("write header: %w", err)
+	}
+
+	// Pass 2: aggregate. Grouped input flushes each group as it ends;
+	// otherwise groups are kept in first-appearance order until the end.
+	type group struct {
+		key   []string
+		cells []pivotCell
+	}
+	var order []*group
+	groups := make(map[string]*group)
+	var cur *group
+	var curKey string
+	flush := func(g *group) error {
+		copy(out, g.key)
+		for i := range g.cells {
+			out[len(keyIdx)+i] = g.cells[i].result(opts.Agg, opts.Fill)
+		}
+		res.RowsWritten++
+		return writer.Write(out)
+	}
+	var n int64
+	err = readRows(ctx, opts.Input, opts.Delimiter, nil, func(row []string) error {
+		step()
+		n++
+		k := rowKey(row, keyIdx, true)
+		if cur == nil || k != curKey {
+			if res.Grouped && cur != nil {
+				if err := flush(cur); err != nil {
+					return err
+				}
+			}
+			cur, curKey = groups[k], k
+			if cur == nil {
+				cur = &group{cells: make([]pivotCell, len(res.Columns))}
+				for _, j := range keyIdx {
+					cur.key = append(cur.key, cell(row, j))
+				}
+				if !res.Grouped {
+					groups[k] = cur
+					order = append(order, cur)
+				}
+			}
+		}
+		c := &cur.cells[colPos[cell(row, pivIdx)]]
+		if c.n > 0 {
+			res.Collisions++
+		}
+		if err := c.add(cell(row, valIdx), opts.Agg); err != nil {
+			return fmt.Errorf("row %d: %w", n, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if res.Grouped && cur != nil {
+		order = append(order, cur)
+	}
+	for _, g := range order {
+		if err := flush(g); err != nil {
+			return res, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return res, fmt.Errorf("writer: %w", err)
+	}
+	return res, nil
+}
+
+// pivotCell accumulates the values of one output cell.
+type pivotCell struct {
+	n           int64 // values added, empty ones included
+	nums        int64 // numeric values added
+	first, last string
+	sum         float64
+	min, max    float64
+}
+
+func (c *pivotCell) add(v string, agg PivotAgg) error {
+	if c.n == 0 {
+		c.first = v
+	}
+	c.last = v
+	c.n++
+	switch agg {
+	case AggSum, AggMin, AggMax, AggMean:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%s needs numbers, got %q", agg, v)
+		}
+		if c.nums == 0 || f < c.min {
+			c.min = f
+		}
+		if c.nums == 0 || f > c.max {
+			c.max = f
+		}
+		c.sum += f
+		c.nums++
+	}
+	return nil
+}
+
+func (c *pivotCell) result(agg PivotAgg, fill string) string {
+	if agg == AggCount {
+		return strconv.FormatInt(c.n, 10)
+	}
+	if c.n == 0 {
+		return fill
+	}
+	switch agg {
+	case AggFirst:
+		return c.first
+	case AggLast:
+		return c.last
+	}
+	if c.nums == 0 {
+		return fill
+	}
+	switch agg {
+	case AggSum:
+		return formatNumber(c.sum)
+	case AggMin:
+		return formatNumber(c.min)
+	case AggMax:
+		return formatNumber(c.max)
+	}
+	return formatNumber(c.sum / float64(c.nums))
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// readRows streams a CSV file, calling onHeader (if set) with the header and
+// onRow with every data row.
+func readRows(ctx context.Context, path string, delim rune, onHeader, onRow func([]string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	h, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read headers: %w", err)
+	}
+	if onHeader != nil {
+		if err := onHeader(h); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := onRow(row); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPivot(t *testing.T) {
+	dir := t.TempDir()
+	grouped := filepath.Join(dir, "grouped.csv")
+	shuffled := filepath.Join(dir, "shuffled.csv")
+	writeCSV(t, grouped, "region,store,month,sales\nEU,1,jan,10\nEU,1,feb,20\nEU,1,jan,5\nUS,2,feb,7\n")
+	writeCSV(t, shuffled, "region,store,month,sales\nEU,1,jan,10\nUS,2,feb,7\nEU,1,feb,20\nEU,1,jan,5\n")
+
+	cases := []struct {
+		agg  PivotAgg
+		want string
+	}{
+		{AggFirst, "region,store,jan,feb\nEU,1,10,20\nUS,2,-,7\n"},
+		{AggLast, "region,store,jan,feb\nEU,1,5,20\nUS,2,-,7\n"},
+		{AggSum, "region,store,jan,feb\nEU,1,15,20\nUS,2,-,7\n"},
+		{AggMean, "region,store,jan,feb\nEU,1,7.5,20\nUS,2,-,7\n"},
+		{AggCount, "region,store,jan,feb\nEU,1,2,1\nUS,2,0,1\n"},
+	}
+	for _, in := range []string{grouped, shuffled} {
+		for _, tc := range cases {
+			var buf bytes.Buffer
+			res, err := Pivot(context.Background(), PivotOptions{
+				Input:       in,
+				Output:      &buf,
+				RowKeys:     []string{"region", "store"},
+				PivotColumn: "month",
+				ValueColumn: "sales",
+				Agg:         tc.agg,
+				Fill:        "-",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Grouped != (in == grouped) {
+				t.Errorf("%s: Grouped = %v", filepath.Base(in), res.Grouped)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("%s %s:\n%s\nwant:\n%s", filepath.Base(in), tc.agg, buf.String(), tc.want)
+			}
+			if res.Collisions != 1 || res.RowsWritten != 2 || strings.Join(res.Columns, ",") != "jan,feb" {
+				t.Errorf("res = %+v", res)
+			}
+		}
+	}
+}
+
+func TestPivot_Guards(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "id,k,v\n1,a,x\n1,b,2\n")
+	opts := PivotOptions{Input: in, Output: &bytes.Buffer{}, RowKeys: []string{"id"}, PivotColumn: "k", ValueColumn: "v"}
+
+	opts.MaxColumns = 1
+	if _, err := Pivot(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "more than 1 distinct") {
+		t.Errorf("MaxColumns: err = %v", err)
+	}
+	opts.MaxColumns, opts.Agg = 0, AggSum
+	if _, err := Pivot(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "row 1") {
+		t.Errorf("sum of text: err = %v", err)
+	}
+}
+
+func TestUnpivot(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "id,label,jan,feb\n1,a,10,\n2,b,30,40\n")
+
+	var buf bytes.Buffer
+	res, err := Unpivot(context.Background(), UnpivotOptions{Input: in, Output: &buf, IDColumns: []string{"id"}, ValueColumns: []string{"jan", "feb"}, NameColumn: "month", SkipEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,month,value\n1,jan,10\n2,jan,30\n2,feb,40\n"
+	if buf.String() != want || res.RowsWritten != 3 || res.TotalRows != 2 {
+		t.Errorf("output:\n%s\nres = %+v", buf.String(), res)
+	}
+
+	buf.Reset()
+	if _, err := Unpivot(context.Background(), UnpivotOptions{Input: in, Output: &buf, IDColumns: []string{"id", "label"}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "id,label,name,value\n1,a,jan,10\n1,a,feb,\n2,b,jan,30\n2,b,feb,40\n"
+	if buf.String() != want {
+		t.Errorf("output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,186 @@
+package csvops
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileOptions configures a Profile operation.
+type ProfileOptions struct {
+	Input     string
+	Delimiter rune
+	// MaxUnique, TopN and Bins behave as in StatsOptions.
+	MaxUnique int
+	TopN      int
+	Bins      int
+	// MaxCorrelationColumns bounds how many columns (in header order) are
+	// considered for pairwise correlation; the cost per row grows with its
+	// square. Default DefaultMaxCorrelationColumns.
+	MaxCorrelationColumns int
+	Progress              Progress
+}
+
+// DefaultMaxCorrelationColumns is used when
+// ProfileOptions.MaxCorrelationColumns is unset.
+const DefaultMaxCorrelationColumns = 50
+
+// ColumnProfile extends ColumnStats (always computed with Patterns) with the
+// inferred type and the share of empty cells.
+type ColumnProfile struct {
+	ColumnStats
+	Type      ColumnType `json:"type"`
+	NullRatio float64    `json:"null_ratio"`
+}
+
+// Correlation is the Pearson correlation coefficient between two numeric
+// columns, computed over the N rows where both have a value.
+type Correlation struct {
+	X string  `json:"x"`
+	Y string  `json:"y"`
+	R float64 `json:"r"`
+	N int64   `json:"n"`
+}
+
+// ProfileResult is returned from Profile.
+type ProfileResult struct {
+	Input        string          `json:"input"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Rows         int64           `json:"rows"`
+	Columns      []ColumnProfile `json:"columns"`
+	Correlations []Correlation   `json:"correlations"`
+}
+
+// Profile computes everything Stats does plus inferred column types, null
+// ratios, value patterns and correlations between numeric columns, all in the
+// same single pass. Render it with WriteProfileHTML or encode it as JSON.
+func Profile(ctx context.Context, opts ProfileOptions) (ProfileResult, error) {
+	res := ProfileResult{Input: opts.Input, GeneratedAt: time.Now().UTC()}
+	if opts.MaxCorrelationColumns <= 0 {
+		opts.MaxCorrelationColumns = DefaultMaxCorrelationColumns
+	}
+
+	var (
+		types []typeAcc
+		corr  *correlationAcc
+	)
+	visit := func(row []string, cols []*columnAcc) {
+		if types == nil {
+			types = make([]typeAcc, len(cols))
+			corr = newCorrelationAcc(min(len(cols), opts.MaxCorrelationColumns))
+		}
+		for i := range cols {
+			v := ""
+			if i < len(row) {
+				v = strings.TrimSpace(row[i])
+			}
+			types[i].add(v)
+		}
+		corr.add(cols)
+	}
+
+	headers, accs, rows, err := scanColumns(ctx, StatsOptions{
+		Input:     opts.Input,
+		Delimiter: opts.Delimiter,
+		MaxUnique: opts.MaxUnique,
+		TopN:      opts.TopN,
+		Bins:      opts.Bins,
+		Patterns:  true,
+		Progress:  opts.Progress,
+	}, visit)
+	if err != nil {
+		return res, err
+	}
+	res.Rows = rows
+
+	res.Columns = make([]ColumnProfile, len(headers))
+	for i, name := range headers {
+		cp := ColumnProfile{ColumnStats: accs[i].result(name), Type: TypeEmpty}
+		if rows > 0 {
+			cp.NullRatio = float64(cp.Empty) / float64(rows)
+		}
+		if types != nil {
+			cp.Type = types[i].result()
+		}
+		res.Columns[i] = cp
+	}
+	if corr != nil {
+		res.Correlations = corr.result(headers, res.Columns)
+	}
+	return res, nil
+}
+
+// pairMoments accumulates the co-moments of two variables with Welford's
+// online update.
+type pairMoments struct {
+	n             int64
+	meanX, meanY  float64
+	m2X, m2Y, cXY float64
+}
+
+func (p *pairMoments) add(x, y float64) {
+	p.n++
+	dx := x - p.meanX
+	p.meanX += dx / float64(p.n)
+	dy := y - p.meanY
+	p.meanY += dy / float64(p.n)
+	p.m2X += dx * (x - p.meanX)
+	p.m2Y += dy * (y - p.meanY)
+	p.cXY += dx * (y - p.meanY)
+}
+
+// correlationAcc tracks every pair among the first k columns, indexed as
+// the upper triangle of a k×k matrix.
+type correlationAcc struct {
+	k     int
+	pairs []pairMoments
+	live  []int // scratch: columns with a numeric value in the current row
+}
+
+func newCorrelationAcc(k int) *correlationAcc {
+	return &correlationAcc{k: k, pairs: make([]pairMoments, k*(k-1)/2)}
+}
+
+func (c *correlationAcc) index(i, j int) int {
+	return i*c.k - i*(i+1)/2 + (j - i - 1)
+}
+
+func (c *correlationAcc) add(cols []*columnAcc) {
+	c.live = c.live[:0]
+	for i := 0; i < c.k; i++ {
+		if cols[i].lastOK {
+			c.live = append(c.live, i)
+		}
+	}
+	for a, i := range c.live {
+		for _, j := range c.live[a+1:] {
+			c.pairs[c.index(i, j)].add(cols[i].last, cols[j].last)
+		}
+	}
+}
+
+// result reports pairs of columns that ended up numeric, strongest first.
+func (c *correlationAcc) result(headers []string, cols []ColumnProfile) []Correlation {
+	var out []Correlation
+	for i := 0; i < c.k; i++ {
+		for j := i + 1; j < c.k; j++ {
+			if cols[i].Numeric == nil || cols[j].Numeric == nil {
+				continue
+			}
+			p := c.pairs[c.index(i, j)]
+			if p.n < 2 || p.m2X == 0 || p.m2Y == 0 {
+				continue
+			}
+			out = append(out, Correlation{
+				X: headers[i],
+				Y: headers[j],
+				R: p.cXY / math.Sqrt(p.m2X*p.m2Y),
+				N: p.n,
+			})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return math.Abs(out[a].R) > math.Abs(out[b].R) })
+	return out
+}
@@ -0,0 +1,48 @@
+package csvops
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+)
+
+//go:embed templates/profile.html
+var profileHTML string
+
+var profileTemplate = template.Must(template.New("profile").Funcs(template.FuncMap{
+	"base": filepath.Base,
+	"pct":  func(f float64) string { return strconv.FormatFloat(100*f, 'f', 1, 64) + "%" },
+	"num":  func(f float64) string { return strconv.FormatFloat(f, 'g', 6, 64) },
+	"r2":   func(f float64) string { return strconv.FormatFloat(f, 'f', 2, 64) },
+	// width scales count against the largest bin as a CSS percentage.
+	"width": func(count int64, bins []HistogramBin) string {
+		var peak int64
+		for _, b := range bins {
+			peak = max(peak, b.Count)
+		}
+		if peak == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(count)/float64(peak))
+	},
+	// heat colours a correlation coefficient: blue for negative, red for
+	// positive, stronger for larger magnitudes.
+	"heat": func(r float64) template.CSS {
+		a := math.Min(math.Abs(r), 1)
+		if r < 0 {
+			return template.CSS(fmt.Sprintf("background: rgba(37, 99, 235, %.2f)", a))
+		}
+		return template.CSS(fmt.Sprintf("background: rgba(220, 38, 38, %.2f)", a))
+	},
+}).Parse(profileHTML))
+
+// WriteProfileHTML renders p as a self-contained HTML report: styles are
+// inline and charts are plain HTML, so the file can be opened offline or
+// attached to a ticket.
+func WriteProfileHTML(w io.Writer, p ProfileResult) error {
+	return profileTemplate.Execute(w, p)
+}
@@ -0,0 +1,70 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfile_TypesPatternsAndCorrelation(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "code,x,y,z,when,flag,note\n"+
+		"EGY-2024,1,2,10,2024-01-05,true,\n"+
+		"USA-1999,2,4,8,2024-02-10,false,hi\n"+
+		"FRA-2001,3,6,9,2024-03-15,yes,\n"+
+		"de-77,4,8,7,2024-04-20,no,\n")
+
+	res, err := Profile(context.Background(), ProfileOptions{Input: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 4 || len(res.Columns) != 7 {
+		t.Fatalf("rows=%d columns=%d", res.Rows, len(res.Columns))
+	}
+
+	wantTypes := []ColumnType{TypeString, TypeInteger, TypeInteger, TypeInteger, TypeDate, TypeBoolean, TypeString}
+	for i, want := range wantTypes {
+		if got := res.Columns[i].Type; got != want {
+			t.Errorf("%s: type = %s, want %s", res.Columns[i].Name, got, want)
+		}
+	}
+
+	code := res.Columns[0]
+	if p := code.Patterns; len(p) != 2 || p[0].Pattern != "AAA-9999" || p[0].Count != 3 || p[0].Ratio != 0.75 || p[1].Pattern != "AA-99" {
+		t.Errorf("patterns = %+v", p)
+	}
+	if note := res.Columns[6]; note.NullRatio != 0.75 {
+		t.Errorf("note NullRatio = %v, want 0.75", note.NullRatio)
+	}
+
+	r := map[string]float64{}
+	for _, c := range res.Correlations {
+		r[c.X+"/"+c.Y] = c.R
+	}
+	if len(r) != 3 {
+		t.Fatalf("correlations = %+v, want x/y, x/z, y/z", res.Correlations)
+	}
+	if math.Abs(r["x/y"]-1) > 1e-9 {
+		t.Errorf("r(x,y) = %v, want 1", r["x/y"])
+	}
+	if r["x/z"] >= 0 {
+		t.Errorf("r(x,z) = %v, want negative", r["x/z"])
+	}
+	if res.Correlations[0].X != "x" || res.Correlations[0].Y != "y" {
+		t.Errorf("strongest correlation first, got %+v", res.Correlations[0])
+	}
+
+	var html bytes.Buffer
+	if err := WriteProfileHTML(&html, res); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>Profile: in.csv</title>", "AAA-9999", "Correlations"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML report missing %q", want)
+		}
+	}
+}
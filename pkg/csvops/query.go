@@ -0,0 +1,352 @@
+package csvops
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// QueryTable maps a table name used in the SQL to a CSV file.
+type QueryTable struct {
+	Name string `json:"name"` // defaults to SanitizeTableName(Path)
+	Path string `json:"path"`
+}
+
+// QueryOptions configures a Query operation.
+type QueryOptions struct {
+	SQL    string
+	Tables []QueryTable
+	// CacheDir, when set, keeps each loaded CSV in its own SQLite file there
+	// and reuses it while the CSV's size and modification time are
+	// unchanged. At most 10 tables can be cached per query. Without it the
+	// CSVs are loaded for this query only.
+	CacheDir string
+	// OnDisk loads uncached tables into a temporary database file rather
+	// than memory, for CSVs larger than RAM. The file is removed afterwards.
+	OnDisk bool
+	// InferRows is how many rows are sampled to infer column types (default
+	// 10,000). Values that don't fit the inferred type are still stored, as
+	// text.
+	InferRows int
+	Delimiter rune
+	// OnColumns, if set, receives the result column names before the first
+	// row, even when the query returns no rows.
+	OnColumns func(columns []string) error
+	// OnRow receives every result row as it is read. Values are int64,
+	// float64, string, []byte or nil (NULL).
+	OnRow func(values []any) error
+	// Progress reports the tables loaded.
+	Progress Progress
+}
+
+// QueryColumn is a column of a loaded table with its SQLite type.
+type QueryColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // INTEGER, REAL or TEXT
+}
+
+// QueryTableInfo reports one loaded table.
+type QueryTableInfo struct {
+	Name    string        `json:"name"`
+	Path    string        `json:"path"`
+	Rows    int64         `json:"rows"`
+	Cached  bool          `json:"cached"` // reused from CacheDir without reloading
+	Columns []QueryColumn `json:"columns"`
+}
+
+// QueryResult is returned from Query.
+type QueryResult struct {
+	Columns []string         `json:"columns"`
+	Rows    int64            `json:"rows"`
+	Tables  []QueryTableInfo `json:"tables"`
+}
+
+// maxCachedTables is SQLite's default limit on attached databases.
+const maxCachedTables = 10
+
+// Query loads CSV files into SQLite tables with inferred column types
+// (INTEGER, REAL or TEXT) and runs a SQL statement over them, streaming the
+// result rows to OnRow. Everything runs on a single connection, so the
+// in-memory database lives as long as the query.
+func Query(ctx context.Context, opts QueryOptions) (QueryResult, error) {
+	var res QueryResult
+
+	if strings.TrimSpace(opts.SQL) == "" {
+		return res, fmt.Errorf("SQL is required")
+	}
+	if opts.CacheDir != "" && len(opts.Tables) > maxCachedTables {
+		return res, fmt.Errorf("at most %d tables can be cached (got %d)", maxCachedTables, len(opts.Tables))
+	}
+	if opts.InferRows <= 0 {
+		opts.InferRows = 10_000
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	dsn := ":memory:"
+	if opts.OnDisk {
+		f, err := os.CreateTemp("", "csvops-query-*.db")
+		if err != nil {
+			return res, fmt.Errorf("create temp database: %w", err)
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+		dsn = f.Name()
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return res, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return res, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer conn.Close()
+
+	if opts.CacheDir != "" {
+		if err := os.MkdirAll(opts.CacheDir, 0o755); err != nil {
+			return res, fmt.Errorf("create cache dir: %w", err)
+		}
+	}
+	for i, t := range opts.Tables {
+		if t.Name == "" {
+			t.Name = SanitizeTableName(t.Path)
+		}
+		var info QueryTableInfo
+		if opts.CacheDir != "" {
+			info, err = attachCached(ctx, conn, fmt.Sprintf("c%d", i), t, opts)
+		} else {
+			info, err = loadQueryTable(ctx, conn, "main", t, opts)
+		}
+		if err != nil {
+			return res, fmt.Errorf("load %s: %w", t.Path, err)
+		}
+		res.Tables = append(res.Tables, info)
+		safeProgress(opts.Progress, int64(i+1), int64(len(opts.Tables)))
+	}
+
+	rows, err := conn.QueryContext(ctx, opts.SQL)
+	if err != nil {
+		return res, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+	if res.Columns, err = rows.Columns(); err != nil {
+		return res, err
+	}
+	if opts.OnColumns != nil {
+		if err := opts.OnColumns(res.Columns); err != nil {
+			return res, err
+		}
+	}
+	values := make([]any, len(res.Columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return res, err
+		}
+		res.Rows++
+		if opts.OnRow != nil {
+			if err := opts.OnRow(values); err != nil {
+				return res, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("query: %w", err)
+	}
+	return res, nil
+}
+
+// attachCached attaches t's cache database as schema, reloading it if the
+// CSV changed, and exposes it under t.Name through a temporary view.
+func attachCached(ctx context.Context, conn *sql.Conn, schema string, t QueryTable, opts QueryOptions) (QueryTableInfo, error) {
+	stat, err := os.Stat(t.Path)
+	if err != nil {
+		return QueryTableInfo{}, err
+	}
+	abs, _ := filepath.Abs(t.Path)
+	sum := sha256.Sum256([]byte(abs + "\x00" + string(opts.Delimiter)))
+	dbPath := filepath.Join(opts.CacheDir, hex.EncodeToString(sum[:8])+".db")
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+QuoteIdent(schema), dbPath); err != nil {
+		return QueryTableInfo{}, fmt.Errorf("attach cache: %w", err)
+	}
+
+	src := QuoteIdent(schema) + "._source"
+	var size, mtime, rowCount int64
+	err = conn.QueryRowContext(ctx, "SELECT size, mtime, row_count FROM "+src).Scan(&size, &mtime, &rowCount)
+	fresh := err == nil && size == stat.Size() && mtime == stat.ModTime().UnixNano()
+
+	info := QueryTableInfo{Name: t.Name, Path: t.Path, Rows: rowCount, Cached: fresh}
+	if !fresh {
+		for _, tbl := range []string{"_source", "data"} {
+			if _, err := conn.ExecContext(ctx, "DROP TABLE IF EXISTS "+QuoteIdent(schema)+"."+tbl); err != nil {
+				return info, err
+			}
+		}
+		loaded, err := loadQueryTable(ctx, conn, schema, QueryTable{Name: "data", Path: t.Path}, opts)
+		if err != nil {
+			return info, err
+		}
+		info.Rows = loaded.Rows
+		if _, err := conn.ExecContext(ctx, "CREATE TABLE "+src+" (path TEXT, size INTEGER, mtime INTEGER, row_count INTEGER)"); err != nil {
+			return info, err
+		}
+		if _, err := conn.ExecContext(ctx, "INSERT INTO "+src+" VALUES (?, ?, ?, ?)", abs, stat.Size(), stat.ModTime().UnixNano(), info.Rows); err != nil {
+			return info, err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TEMP VIEW %s AS SELECT * FROM %s.data", QuoteIdent(t.Name), QuoteIdent(schema))); err != nil {
+		return info, fmt.Errorf("create view: %w", err)
+	}
+	info.Columns, err = tableColumns(ctx, conn, schema, "data")
+	return info, err
+}
+
+// loadQueryTable creates schema.t.Name with column types inferred from the
+// first InferRows rows and inserts every row in one transaction. Empty
+// values are NULL in INTEGER and REAL columns and "" in TEXT ones.
+func loadQueryTable(ctx context.Context, conn *sql.Conn, schema string, t QueryTable, opts QueryOptions) (QueryTableInfo, error) {
+	info := QueryTableInfo{Name: t.Name, Path: t.Path}
+
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return info, fmt.Errorf("read header: %w", err)
+	}
+
+	accs := make([]typeAcc, len(header))
+	var sample [][]string
+	for len(sample) < opts.InferRows {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return info, err
+		}
+		for i := range accs {
+			accs[i].add(strings.TrimSpace(cell(row, i)))
+		}
+		sample = append(sample, row)
+	}
+	cols := make([]string, len(header))
+	for i, h := range header {
+		typ := "TEXT"
+		switch accs[i].result() {
+		case TypeInteger:
+			typ = "INTEGER"
+		case TypeNumber:
+			typ = "REAL"
+		}
+		info.Columns = append(info.Columns, QueryColumn{Name: h, Type: typ})
+		cols[i] = QuoteIdent(h) + " " + typ
+	}
+
+	table := QuoteIdent(schema) + "." + QuoteIdent(t.Name)
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(cols, ", "))); err != nil {
+		return info, fmt.Errorf("create table: %w", err)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return info, fmt.Errorf("begin tx: %w", err)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(header)), ",")
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", table, placeholders))
+	if err != nil {
+		_ = tx.Rollback()
+		return info, fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	vals := make([]any, len(header))
+	insert := func(row []string) error {
+		for i, c := range info.Columns {
+			vals[i] = sqlValue(cell(row, i), c.Type)
+		}
+		if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+			return fmt.Errorf("insert row: %w", err)
+		}
+		info.Rows++
+		return nil
+	}
+	for _, row := range sample {
+		if err := insert(row); err != nil {
+			_ = tx.Rollback()
+			return info, err
+		}
+	}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = insert(row)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return info, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return info, fmt.Errorf("commit: %w", err)
+	}
+	return info, nil
+}
+
+// sqlValue converts a CSV value for a column of the given SQLite type,
+// falling back to the text when it doesn't parse.
+func sqlValue(v, typ string) any {
+	if typ == "TEXT" {
+		return v
+	}
+	t := strings.TrimSpace(v)
+	if t == "" {
+		return nil
+	}
+	if typ == "INTEGER" {
+		if n, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return n
+		}
+	} else if f, err := strconv.ParseFloat(t, 64); err == nil {
+		return f
+	}
+	return v
+}
+
+func tableColumns(ctx context.Context, conn *sql.Conn, schema, table string) ([]QueryColumn, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?, ?)", table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []QueryColumn
+	for rows.Next() {
+		var c QueryColumn
+		if err := rows.Scan(&c.Name, &c.Type); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
@@ -0,0 +1,83 @@
+package csvops
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.csv")
+	orders := filepath.Join(dir, "orders.csv")
+	writeCSV(t, users, "id,name,country\n1,Ann,EG\n2,Bob,EG\n3,Cy,FR\n")
+	writeCSV(t, orders, "user_id,total\n1,9.5\n1,10\n3,\n")
+
+	var got []string
+	res, err := Query(context.Background(), QueryOptions{
+		SQL: `SELECT u.country, count(DISTINCT u.id), sum(o.total)
+		      FROM users u LEFT JOIN orders o ON o.user_id = u.id
+		      GROUP BY 1 ORDER BY 1`,
+		Tables: []QueryTable{{Path: users}, {Name: "orders", Path: orders}},
+		OnRow: func(values []any) error {
+			got = append(got, fmt.Sprintf("%v/%T %v/%T %v/%T", values[0], values[0], values[1], values[1], values[2], values[2]))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"EG/string 2/int64 19.5/float64", "FR/string 1/int64 <nil>/<nil>"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("rows = %q", got)
+	}
+	if res.Rows != 2 || len(res.Columns) != 3 || res.Tables[0].Name != "users" || res.Tables[1].Rows != 3 {
+		t.Errorf("res = %+v", res)
+	}
+	types := fmt.Sprint(res.Tables[0].Columns, res.Tables[1].Columns)
+	if types != "[{id INTEGER} {name TEXT} {country TEXT}] [{user_id INTEGER} {total REAL}]" {
+		t.Errorf("types = %s", types)
+	}
+}
+
+func TestQuery_CacheReusedUntilFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "data.csv")
+	cache := filepath.Join(dir, "cache")
+	writeCSV(t, in, "n\n1\n2\n")
+
+	run := func() (QueryResult, int64) {
+		t.Helper()
+		var sum int64
+		res, err := Query(context.Background(), QueryOptions{
+			SQL:      "SELECT sum(n) FROM data",
+			Tables:   []QueryTable{{Path: in}},
+			CacheDir: cache,
+			OnRow:    func(v []any) error { sum = v[0].(int64); return nil },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res, sum
+	}
+
+	if res, sum := run(); res.Tables[0].Cached || sum != 3 {
+		t.Errorf("first run: cached = %v, sum = %d", res.Tables[0].Cached, sum)
+	}
+	if res, sum := run(); !res.Tables[0].Cached || sum != 3 || res.Tables[0].Rows != 2 {
+		t.Errorf("second run: %+v, sum = %d", res.Tables[0], sum)
+	}
+
+	writeCSV(t, in, "n\n1\n2\n3\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(in, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if res, sum := run(); res.Tables[0].Cached || sum != 6 {
+		t.Errorf("after change: cached = %v, sum = %d", res.Tables[0].Cached, sum)
+	}
+}
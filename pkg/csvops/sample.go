@@ -0,0 +1,198 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"time"
+)
+
+// SampleOptions configures a Sample operation. Exactly one of Fraction and
+// Size must be set.
+type SampleOptions struct {
+	Input  string
+	Output io.Writer
+	// Fraction keeps each row independently with this probability
+	// (Bernoulli sampling), e.g. 0.01 for a ~1% sample. Streams in constant memory.
+	Fraction float64
+	// Size keeps exactly Size rows (or every row, if there are fewer) chosen
+	// uniformly at random in one pass via reservoir sampling. Holds Size rows
+	// in memory.
+	Size int
+	// StratifyBy samples each distinct value of this column separately:
+	// Fraction or Size then applies per stratum.
+	StratifyBy string
+	// Seed makes the sample reproducible. 0 picks a random seed, which is
+	// reported in SampleResult.Seed.
+	Seed       int64
+	WithHeader bool
+	Delimiter  rune
+	Progress   Progress
+}
+
+// StratumCount reports how many rows a stratum had and how many were kept.
+type StratumCount struct {
+	Value   string `json:"value"`
+	Rows    int64  `json:"rows"`
+	Sampled int64  `json:"sampled"`
+}
+
+// SampleResult is returned from Sample.
+type SampleResult struct {
+	TotalRows int64          `json:"total_rows"`
+	Sampled   int64          `json:"sampled"`
+	Seed      int64          `json:"seed"`
+	Strata    []StratumCount `json:"strata,omitempty"` // sorted by value; empty unless StratifyBy is set
+}
+
+// sampledRow is a reservoir entry; idx keeps output in input order.
+type sampledRow struct {
+	idx int64
+	row []string
+}
+
+// Sample writes a random subset of the input rows to opts.Output. Rows are
+// emitted in their original order.
+func Sample(ctx context.Context, opts SampleOptions) (SampleResult, error) {
+	var res SampleResult
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	if (opts.Fraction > 0) == (opts.Size > 0) {
+		return res, fmt.Errorf("exactly one of Fraction and Size must be set")
+	}
+	if opts.Fraction < 0 || opts.Fraction > 1 {
+		return res, fmt.Errorf("Fraction must be between 0 and 1 (got %v)", opts.Fraction)
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	res.Seed = opts.Seed
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), uint64(opts.Seed)>>1|1))
+
+	total, err := CountDataRows(opts.Input, opts.Delimiter)
+	if err != nil {
+		return res, err
+	}
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comma = opts.Delimiter
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err != nil {
+		return res, fmt.Errorf("read header: %w", err)
+	}
+
+	stratCol := -1
+	if opts.StratifyBy != "" {
+		idx, err := resolveKeyIndexes(headers, []string{opts.StratifyBy}, true)
+		if err != nil {
+			return res, err
+		}
+		stratCol = idx[0]
+	}
+
+	writer := csv.NewWriter(opts.Output)
+	writer.Comma = opts.Delimiter
+	if opts.WithHeader {
+		if err := writer.Write(headers); err != nil {
+			return res, fmt.Errorf("write header: %w", err)
+		}
+	}
+
+	strata := map[string]*StratumCount{}
+	reservoirs := map[string][]sampledRow{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		idx := res.TotalRows
+		res.TotalRows++
+		safeProgress(opts.Progress, res.TotalRows, total)
+
+		key := ""
+		if stratCol >= 0 && stratCol < len(row) {
+			key = row[stratCol]
+		}
+		st := strata[key]
+		if st == nil {
+			st = &StratumCount{Value: key}
+			strata[key] = st
+		}
+		seen := st.Rows
+		st.Rows++
+
+		if opts.Fraction > 0 {
+			if rng.Float64() < opts.Fraction {
+				if err := writer.Write(row); err != nil {
+					return res, fmt.Errorf("write row: %w", err)
+				}
+				st.Sampled++
+			}
+			continue
+		}
+
+		// Reservoir sampling (Algorithm R): the n-th row replaces a random
+		// slot with probability Size/n.
+		r := reservoirs[key]
+		if len(r) < opts.Size {
+			reservoirs[key] = append(r, sampledRow{idx: idx, row: row})
+		} else if j := rng.Int64N(seen + 1); j < int64(opts.Size) {
+			r[j] = sampledRow{idx: idx, row: row}
+		}
+	}
+
+	if opts.Size > 0 {
+		var kept []sampledRow
+		for key, r := range reservoirs {
+			strata[key].Sampled = int64(len(r))
+			kept = append(kept, r...)
+		}
+		sort.Slice(kept, func(i, j int) bool { return kept[i].idx < kept[j].idx })
+		for _, s := range kept {
+			if err := writer.Write(s.row); err != nil {
+				return res, fmt.Errorf("write row: %w", err)
+			}
+		}
+	}
+
+	for _, st := range strata {
+		res.Sampled += st.Sampled
+		if stratCol >= 0 {
+			res.Strata = append(res.Strata, *st)
+		}
+	}
+	sort.Slice(res.Strata, func(i, j int) bool { return res.Strata[i].Value < res.Strata[j].Value })
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return res, fmt.Errorf("writer: %w", err)
+	}
+	return res, nil
+}
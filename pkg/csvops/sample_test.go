@@ -0,0 +1,136 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeNumbered(t *testing.T, path string, n int) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("id,group\n")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%d,%s\n", i, []string{"a", "b"}[i%2])
+	}
+	writeCSV(t, path, b.String())
+}
+
+func TestSample_ReservoirExactSizeInOrder(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeNumbered(t, in, 1000)
+
+	var buf bytes.Buffer
+	res, err := Sample(context.Background(), SampleOptions{
+		Input: in, Output: &buf, Size: 10, Seed: 42, WithHeader: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRows != 1000 || res.Sampled != 10 {
+		t.Fatalf("res = %+v, want 1000 total / 10 sampled", res)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id,group" || len(lines) != 11 {
+		t.Fatalf("output:\n%s", buf.String())
+	}
+	prev := 0
+	for _, l := range lines[1:] {
+		id, _ := strconv.Atoi(strings.Split(l, ",")[0])
+		if id <= prev {
+			t.Fatalf("rows not in input order: %v", lines[1:])
+		}
+		prev = id
+	}
+}
+
+func TestSample_SeedIsReproducible(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeNumbered(t, in, 500)
+
+	run := func(opts SampleOptions) string {
+		var buf bytes.Buffer
+		opts.Input, opts.Output = in, &buf
+		if _, err := Sample(context.Background(), opts); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	for _, opts := range []SampleOptions{{Size: 20, Seed: 7}, {Fraction: 0.1, Seed: 7}} {
+		if a, b := run(opts), run(opts); a != b {
+			t.Errorf("%+v: same seed gave different samples", opts)
+		}
+	}
+	if run(SampleOptions{Size: 20, Seed: 7}) == run(SampleOptions{Size: 20, Seed: 8}) {
+		t.Error("different seeds gave identical samples")
+	}
+}
+
+func TestSample_FractionRoughlyMatches(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeNumbered(t, in, 10000)
+
+	var buf bytes.Buffer
+	res, err := Sample(context.Background(), SampleOptions{
+		Input: in, Output: &buf, Fraction: 0.1, Seed: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Binomial(10000, 0.1) has sd 30; allow a wide margin.
+	if res.Sampled < 850 || res.Sampled > 1150 {
+		t.Errorf("Sampled = %d, want ~1000", res.Sampled)
+	}
+	if got := int64(strings.Count(buf.String(), "\n")); got != res.Sampled {
+		t.Errorf("wrote %d rows, result says %d", got, res.Sampled)
+	}
+}
+
+func TestSample_StratifiedPerStratumSize(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id,group\n1,a\n2,a\n3,a\n4,a\n5,b\n6,c\n7,c\n")
+
+	var buf bytes.Buffer
+	res, err := Sample(context.Background(), SampleOptions{
+		Input: in, Output: &buf, Size: 2, StratifyBy: "group", Seed: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StratumCount{{"a", 4, 2}, {"b", 1, 1}, {"c", 2, 2}}
+	if len(res.Strata) != len(want) {
+		t.Fatalf("Strata = %+v", res.Strata)
+	}
+	for i, w := range want {
+		if res.Strata[i] != w {
+			t.Errorf("Strata[%d] = %+v, want %+v", i, res.Strata[i], w)
+		}
+	}
+	if res.Sampled != 5 {
+		t.Errorf("Sampled = %d, want 5", res.Sampled)
+	}
+}
+
+func TestSample_ValidatesOptions(t *testing.T) {
+	var buf bytes.Buffer
+	bad := []SampleOptions{
+		{Input: "x", Output: &buf},
+		{Input: "x", Output: &buf, Size: 5, Fraction: 0.5},
+		{Input: "x", Output: &buf, Fraction: 1.5},
+		{Input: "x", Size: 5},
+	}
+	for _, opts := range bad {
+		if _, err := Sample(context.Background(), opts); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+}
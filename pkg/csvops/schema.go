@@ -0,0 +1,418 @@
+package csvops
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema describes the contract a CSV file must meet. It follows the
+// Frictionless Table Schema (fields, constraints, primaryKey, missingValues)
+// and adds Rules for cross-column checks.
+type Schema struct {
+	Fields []SchemaField `json:"fields"`
+	// PrimaryKey is one or more field names whose combined values must be
+	// unique and present. A single string is accepted in JSON.
+	PrimaryKey StringList `json:"primaryKey,omitempty"`
+	// MissingValues are the cell values treated as empty (default [""]).
+	MissingValues []string     `json:"missingValues,omitempty"`
+	Rules         []SchemaRule `json:"rules,omitempty"`
+}
+
+// SchemaField describes one column.
+type SchemaField struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type,omitempty"` // default string; "any" skips the type check
+	// Format is a strftime-style layout (e.g. "%d/%m/%Y") for date and
+	// datetime fields. "", "default" and "any" accept ISO 8601. String fields
+	// take the Table Schema formats email, uri, uuid and binary (base64).
+	// Other types, and other string formats, only accept "" and "default".
+	Format      string            `json:"format,omitempty"`
+	TrueValues  []string          `json:"trueValues,omitempty"`
+	FalseValues []string          `json:"falseValues,omitempty"`
+	Constraints SchemaConstraints `json:"constraints,omitempty"`
+}
+
+// SchemaConstraints are the per-field checks. Minimum and Maximum are
+// numbers for integer and number fields and ISO strings for dates, whatever
+// the field's Format.
+type SchemaConstraints struct {
+	Required  bool     `json:"required,omitempty"`
+	Unique    bool     `json:"unique,omitempty"`
+	Minimum   any      `json:"minimum,omitempty"`
+	Maximum   any      `json:"maximum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"` // must match the whole value
+	Enum      []string `json:"enum,omitempty"`
+}
+
+// SchemaRule compares two columns of the same row, e.g.
+// {"left": "end_date", "op": ">=", "right": "start_date"}. Values are compared
+// as the left field's type. Rows where either side is missing are skipped.
+type SchemaRule struct {
+	Name  string `json:"name,omitempty"`
+	Left  string `json:"left"`
+	Op    string `json:"op"` // one of = != < <= > >=
+	Right string `json:"right"`
+}
+
+// TypeAny disables type checking for a schema field.
+const TypeAny ColumnType = "any"
+
+// StringList unmarshals from either a JSON string or an array of strings.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = StringList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("want a string or an array of strings")
+	}
+	*l = many
+	return nil
+}
+
+// LoadSchema reads a JSON schema file and checks that it is well formed.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if _, err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// cellValue is a parsed cell. Numbers, booleans and times compare on num
+// (times as Unix seconds); strings on str.
+type cellValue struct {
+	num     float64
+	str     string
+	ordered bool // num is meaningful
+}
+
+func compareValues(a, b cellValue) int {
+	if a.ordered && b.ordered {
+		switch {
+		case a.num < b.num:
+			return -1
+		case a.num > b.num:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.str, b.str)
+}
+
+// compiledField is a SchemaField ready to check cells.
+type compiledField struct {
+	SchemaField
+	index   int // column position, -1 when missing from the file
+	parse   func(string) (cellValue, error)
+	min     *cellValue
+	max     *cellValue
+	pattern *regexp.Regexp
+	enum    map[string]bool
+}
+
+type compiledRule struct {
+	SchemaRule
+	left, right int // field positions in compiledSchema.fields
+}
+
+type compiledSchema struct {
+	fields  []*compiledField
+	byName  map[string]int
+	missing map[string]bool
+	rules   []compiledRule
+	pk      []int
+}
+
+func (s *Schema) compile() (*compiledSchema, error) {
+	if len(s.Fields) == 0 {
+		return nil, fmt.Errorf("schema: no fields")
+	}
+	cs := &compiledSchema{byName: make(map[string]int), missing: make(map[string]bool)}
+	missing := s.MissingValues
+	if missing == nil {
+		missing = []string{""}
+	}
+	for _, m := range missing {
+		cs.missing[m] = true
+	}
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("schema: field %d has no name", i+1)
+		}
+		if _, dup := cs.byName[f.Name]; dup {
+			return nil, fmt.Errorf("schema: field %q declared twice", f.Name)
+		}
+		cf, err := compileField(f)
+		if err != nil {
+			return nil, fmt.Errorf("schema: field %q: %w", f.Name, err)
+		}
+		cs.byName[f.Name] = i
+		cs.fields = append(cs.fields, cf)
+	}
+	for _, name := range s.PrimaryKey {
+		i, ok := cs.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("schema: primaryKey field %q is not declared", name)
+		}
+		cs.pk = append(cs.pk, i)
+	}
+	for _, r := range s.Rules {
+		switch r.Op {
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("schema: rule %s: unknown op %q", r.label(), r.Op)
+		}
+		l, lok := cs.byName[r.Left]
+		rt, rok := cs.byName[r.Right]
+		if !lok || !rok {
+			return nil, fmt.Errorf("schema: rule %s: fields must be declared", r.label())
+		}
+		cs.rules = append(cs.rules, compiledRule{SchemaRule: r, left: l, right: rt})
+	}
+	return cs, nil
+}
+
+func (r SchemaRule) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s %s %s", r.Left, r.Op, r.Right)
+}
+
+func compileField(f SchemaField) (*compiledField, error) {
+	if f.Type == "" {
+		f.Type = TypeString
+	}
+	cf := &compiledField{SchemaField: f, index: -1}
+	var parseBound func(v string) (cellValue, error) // when bounds differ from cells
+	switch f.Type {
+	case TypeDate, TypeDatetime, TypeString:
+	default:
+		if f.Format != "" && f.Format != "default" {
+			return nil, fmt.Errorf("format %q is not supported for %s fields", f.Format, f.Type)
+		}
+	}
+	switch f.Type {
+	case TypeString:
+		check, err := stringFormat(f.Format)
+		if err != nil {
+			return nil, err
+		}
+		cf.parse = func(v string) (cellValue, error) {
+			if check != nil {
+				if err := check(v); err != nil {
+					return cellValue{}, err
+				}
+			}
+			return cellValue{str: v}, nil
+		}
+	case TypeAny:
+		cf.parse = func(v string) (cellValue, error) { return cellValue{str: v}, nil }
+	case TypeInteger:
+		cf.parse = func(v string) (cellValue, error) {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return cellValue{}, fmt.Errorf("not an integer")
+			}
+			return cellValue{num: float64(n), str: v, ordered: true}, nil
+		}
+	case TypeNumber:
+		cf.parse = func(v string) (cellValue, error) {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+				return cellValue{}, fmt.Errorf("not a number")
+			}
+			return cellValue{num: n, str: v, ordered: true}, nil
+		}
+	case TypeBoolean:
+		trues, falses := f.TrueValues, f.FalseValues
+		if trues == nil {
+			trues = []string{"true", "True", "TRUE", "1"}
+		}
+		if falses == nil {
+			falses = []string{"false", "False", "FALSE", "0"}
+		}
+		cf.parse = func(v string) (cellValue, error) {
+			for _, t := range trues {
+				if v == t {
+					return cellValue{num: 1, str: v, ordered: true}, nil
+				}
+			}
+			for _, t := range falses {
+				if v == t {
+					return cellValue{num: 0, str: v, ordered: true}, nil
+				}
+			}
+			return cellValue{}, fmt.Errorf("not a boolean")
+		}
+	case TypeDate, TypeDatetime:
+		iso := dateLayouts[:1]
+		if f.Type == TypeDatetime {
+			iso = append(slices.Clone(datetimeLayouts), dateLayouts[0])
+		}
+		layouts := iso
+		if f.Format != "" && f.Format != "default" && f.Format != "any" {
+			layouts = []string{strftimeLayout(f.Format)}
+		}
+		cf.parse = dateParser(f.Type, layouts)
+		// Bounds are ISO whatever the cells' format; one written in that
+		// format is accepted too.
+		parseBound = dateParser(f.Type, append(slices.Clone(iso), layouts...))
+	default:
+		return nil, fmt.Errorf("unknown type %q", f.Type)
+	}
+
+	c := f.Constraints
+	for _, bound := range []struct {
+		raw any
+		dst **cellValue
+	}{{c.Minimum, &cf.min}, {c.Maximum, &cf.max}} {
+		if bound.raw == nil {
+			continue
+		}
+		s := fmt.Sprint(bound.raw)
+		if n, ok := bound.raw.(float64); ok {
+			s = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		parse := cf.parse
+		if parseBound != nil {
+			parse = parseBound
+		}
+		v, err := parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("bound %q: %v", s, err)
+		}
+		*bound.dst = &v
+	}
+	if c.Pattern != "" {
+		re, err := regexp.Compile(`^(?:` + c.Pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+		cf.pattern = re
+	}
+	if c.Enum != nil {
+		cf.enum = make(map[string]bool, len(c.Enum))
+		for _, e := range c.Enum {
+			cf.enum[e] = true
+		}
+	}
+	return cf, nil
+}
+
+// stringFormat returns the check for a string field's format, nil for none.
+func stringFormat(format string) (func(v string) error, error) {
+	switch format {
+	case "", "default":
+		return nil, nil
+	case "email":
+		return func(v string) error {
+			if !emailRe.MatchString(v) {
+				return fmt.Errorf("not an email")
+			}
+			return nil
+		}, nil
+	case "uri":
+		return func(v string) error {
+			if u, err := url.Parse(v); err != nil || u.Scheme == "" {
+				return fmt.Errorf("not a URI")
+			}
+			return nil
+		}, nil
+	case "uuid":
+		return func(v string) error {
+			if !uuidRe.MatchString(v) {
+				return fmt.Errorf("not a UUID")
+			}
+			return nil
+		}, nil
+	case "binary":
+		return func(v string) error {
+			if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+				return fmt.Errorf("not base64")
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("format %q is not supported for string fields (want email, uri, uuid or binary)", format)
+}
+
+// dateParser parses date or datetime cells with the first matching layout.
+func dateParser(typ ColumnType, layouts []string) func(v string) (cellValue, error) {
+	return func(v string) (cellValue, error) {
+		for _, l := range layouts {
+			if t, err := time.Parse(l, v); err == nil {
+				return cellValue{num: float64(t.UnixNano()) / 1e9, str: v, ordered: true}, nil
+			}
+		}
+		return cellValue{}, fmt.Errorf("not a %s", typ)
+	}
+}
+
+// checkCell returns the rule and message of every constraint v breaks; v is
+// already known to be non-missing.
+func (f *compiledField) checkCell(v string) (cellValue, []Violation) {
+	var out []Violation
+	bad := func(rule, msg string) {
+		out = append(out, Violation{Column: f.Name, Value: v, Rule: rule, Message: msg})
+	}
+	val, err := f.parse(v)
+	if err != nil {
+		bad("type", err.Error())
+		return val, out
+	}
+	c := f.Constraints
+	if f.min != nil && compareValues(val, *f.min) < 0 {
+		bad("minimum", fmt.Sprintf("below minimum %s", f.min.str))
+	}
+	if f.max != nil && compareValues(val, *f.max) > 0 {
+		bad("maximum", fmt.Sprintf("above maximum %s", f.max.str))
+	}
+	if n := utf8.RuneCountInString(v); c.MinLength != nil && n < *c.MinLength {
+		bad("minLength", fmt.Sprintf("shorter than %d characters", *c.MinLength))
+	} else if c.MaxLength != nil && n > *c.MaxLength {
+		bad("maxLength", fmt.Sprintf("longer than %d characters", *c.MaxLength))
+	}
+	if f.pattern != nil && !f.pattern.MatchString(v) {
+		bad("pattern", fmt.Sprintf("does not match %s", c.Pattern))
+	}
+	if f.enum != nil && !f.enum[v] {
+		bad("enum", fmt.Sprintf("not one of %s", strings.Join(c.Enum, ", ")))
+	}
+	return val, out
+}
+
+// strftimeLayout converts the common strftime directives to a Go layout.
+func strftimeLayout(format string) string {
+	r := strings.NewReplacer(
+		"%Y", "2006", "%y", "06", "%m", "01", "%d", "02", "%e", "_2",
+		"%H", "15", "%I", "03", "%M", "04", "%S", "05", "%p", "PM",
+		"%b", "Jan", "%B", "January", "%a", "Mon", "%A", "Monday",
+		"%z", "-0700", "%Z", "MST", "%f", "000000", "%%", "%",
+	)
+	return r.Replace(format)
+}
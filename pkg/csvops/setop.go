@@ -0,0 +1,397 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// SetOperation selects what SetOp computes.
+type SetOperation string
+
+const (
+	// SetUnion keeps one row per distinct key across all inputs, the first
+	// one seen in input order.
+	SetUnion SetOperation = "union"
+	// SetIntersect keeps the rows of the first input whose key appears in
+	// every other input.
+	SetIntersect SetOperation = "intersect"
+	// SetExcept keeps the rows of the first input whose key appears in none
+	// of the other inputs.
+	SetExcept SetOperation = "except"
+)
+
+// SetOpOptions configures a SetOp operation.
+type SetOpOptions struct {
+	Op SetOperation
+	// Inputs are two or more CSV files with headers. The output has the
+	// first file's header; rows of other files are matched to it by column
+	// name, with missing columns left empty.
+	Inputs []string
+	// KeyColumns decide which rows are the same, as in Dedupe. Empty means
+	// the whole row: every column of the first file's header, with a column
+	// another file lacks counting as empty there, as it is in the output.
+	KeyColumns    []string
+	CaseSensitive bool
+	Output        io.Writer
+	// MemoryRows bounds memory as in DiffOptions (default 1,000,000). When the
+	// inputs together have more rows, each is sorted by key on disk and the
+	// output comes out in key order instead of input order.
+	MemoryRows int
+	Delimiter  rune
+	Progress   Progress
+}
+
+// SetOpFile reports one input of SetOp.
+type SetOpFile struct {
+	Path string `json:"path"`
+	Rows int64  `json:"rows"`
+}
+
+// SetOpResult is returned from SetOp.
+type SetOpResult struct {
+	Op          SetOperation `json:"op"`
+	Strategy    string       `json:"strategy"` // DiffHash or DiffSortMerge
+	Files       []SetOpFile  `json:"files"`
+	RowsWritten int64        `json:"rows_written"`
+}
+
+// SetOp computes the distinct union, intersection or difference of CSV files
+// by key and writes the resulting rows to opts.Output with the first file's
+// header.
+func SetOp(ctx context.Context, opts SetOpOptions) (SetOpResult, error) {
+	res := SetOpResult{Op: opts.Op}
+
+	switch opts.Op {
+	case SetUnion, SetIntersect, SetExcept:
+	default:
+		return res, fmt.Errorf("unknown set operation %q (want union, intersect or except)", opts.Op)
+	}
+	if len(opts.Inputs) < 2 {
+		return res, fmt.Errorf("at least two inputs are required")
+	}
+	if opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.MemoryRows <= 0 {
+		opts.MemoryRows = 1_000_000
+	}
+
+	var total int64
+	for _, path := range opts.Inputs {
+		n, err := CountDataRows(path, opts.Delimiter)
+		if err != nil {
+			return res, err
+		}
+		res.Files = append(res.Files, SetOpFile{Path: path, Rows: n})
+		total += n
+	}
+
+	inputs := make([]*setInput, len(opts.Inputs))
+	for i, path := range opts.Inputs {
+		in, err := openSetInput(path, opts, inputs)
+		if err != nil {
+			return res, err
+		}
+		defer in.f.Close()
+		inputs[i] = in
+	}
+
+	s := &setOp{opts: opts, res: &res, inputs: inputs, total: total}
+	s.w = csv.NewWriter(opts.Output)
+	s.w.Comma = opts.Delimiter
+	if err := s.w.Write(inputs[0].header); err != nil {
+		return res, fmt.Errorf("write header: %w", err)
+	}
+
+	var err error
+	if total <= int64(opts.MemoryRows) {
+		res.Strategy = DiffHash
+		err = s.hash(ctx)
+	} else {
+		res.Strategy = DiffSortMerge
+		err = s.sortMerge(ctx)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	s.w.Flush()
+	if err := s.w.Error(); err != nil {
+		return res, fmt.Errorf("writer: %w", err)
+	}
+	return res, nil
+}
+
+// setInput is one open input with its key columns and its mapping to the
+// first file's columns.
+type setInput struct {
+	path   string
+	f      *os.File
+	r      *csv.Reader
+	header []string
+	keyIdx []int // -1 for a whole-row key column the input lacks
+	proj   []int // nil for the first input
+
+	// keyRow and keyPos gather the key fields when some are missing.
+	keyRow []string
+	keyPos []int
+}
+
+func openSetInput(path string, opts SetOpOptions, prev []*setInput) (*setInput, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	r := csv.NewReader(f)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: read headers: %w", path, err)
+	}
+	in := &setInput{path: path, f: f, r: r, header: header}
+
+	keys := opts.KeyColumns
+	if prev[0] != nil {
+		in.proj = projectColumns(header, prev[0].header)
+		if len(keys) == 0 {
+			in.wholeRowKey(prev[0].header, opts.CaseSensitive)
+			return in, nil
+		}
+	} else if len(keys) == 0 {
+		keys = header
+	}
+	if in.keyIdx, err = resolveKeyIndexes(header, keys, opts.CaseSensitive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return in, nil
+}
+
+// wholeRowKey keys the input on the columns of the first input's header,
+// treating those it doesn't have as empty.
+func (in *setInput) wholeRowKey(cols []string, caseSensitive bool) {
+	in.keyIdx = make([]int, len(cols))
+	for k, c := range cols {
+		in.keyIdx[k] = -1
+		if idx, err := resolveKeyIndexes(in.header, []string{c}, caseSensitive); err == nil {
+			in.keyIdx[k] = idx[0]
+		}
+	}
+	if slices.Contains(in.keyIdx, -1) {
+		in.keyRow = make([]string, len(cols))
+		in.keyPos = make([]int, len(cols))
+		for k := range in.keyPos {
+			in.keyPos[k] = k
+		}
+	}
+}
+
+func (in *setInput) key(row []string, caseSensitive bool) string {
+	if in.keyRow == nil {
+		return rowKey(row, in.keyIdx, caseSensitive)
+	}
+	for k, j := range in.keyIdx {
+		in.keyRow[k] = cell(row, j)
+	}
+	return BuildDedupeKey(in.keyRow, in.keyPos, caseSensitive)
+}
+
+// setOp holds the state shared by the two strategies.
+type setOp struct {
+	opts   SetOpOptions
+	res    *SetOpResult
+	inputs []*setInput
+	w      *csv.Writer
+	out    []string
+	total  int64
+	done   int64
+}
+
+func (s *setOp) progress() {
+	s.done++
+	safeProgress(s.opts.Progress, s.done, s.total)
+}
+
+// write outputs a row of input i in the first input's column order.
+func (s *setOp) write(i int, row []string) error {
+	if proj := s.inputs[i].proj; proj != nil {
+		if s.out == nil {
+			s.out = make([]string, len(proj))
+		}
+		for k, j := range proj {
+			s.out[k] = cell(row, j)
+		}
+		row = s.out
+	}
+	s.res.RowsWritten++
+	return s.w.Write(row)
+}
+
+// each calls fn for every row of input i.
+func (s *setOp) each(ctx context.Context, i int, fn func(row []string) error) error {
+	in := s.inputs[i]
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := in.r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.path, err)
+		}
+		s.progress()
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// setMark counts how many of the other inputs contain a key.
+type setMark struct {
+	last  int // the last input counted
+	count int
+}
+
+// hash keeps keys in memory and writes rows in input order.
+func (s *setOp) hash(ctx context.Context) error {
+	cs := s.opts.CaseSensitive
+	written := make(map[string]struct{})
+
+	if s.opts.Op == SetUnion {
+		for i, in := range s.inputs {
+			err := s.each(ctx, i, func(row []string) error {
+				k := in.key(row, cs)
+				if _, ok := written[k]; ok {
+					return nil
+				}
+				written[k] = struct{}{}
+				return s.write(i, row)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	marks := make(map[string]*setMark)
+	for i := 1; i < len(s.inputs); i++ {
+		in := s.inputs[i]
+		err := s.each(ctx, i, func(row []string) error {
+			k := in.key(row, cs)
+			m := marks[k]
+			if m == nil {
+				m = &setMark{}
+				marks[k] = m
+			}
+			if m.last != i {
+				m.last, m.count = i, m.count+1
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	first := s.inputs[0]
+	return s.each(ctx, 0, func(row []string) error {
+		k := first.key(row, cs)
+		if _, ok := written[k]; ok {
+			return nil
+		}
+		if !s.keep(marks[k] != nil, marks[k] != nil && marks[k].count == len(s.inputs)-1) {
+			return nil
+		}
+		written[k] = struct{}{}
+		return s.write(0, row)
+	})
+}
+
+// keep decides whether a key of the first input is written, given whether
+// any or all of the other inputs contain it.
+func (s *setOp) keep(inAny, inAll bool) bool {
+	if s.opts.Op == SetIntersect {
+		return inAll
+	}
+	return !inAny
+}
+
+// sortMerge sorts every input by key on disk and walks them together, so
+// only the current row of each input is in memory.
+func (s *setOp) sortMerge(ctx context.Context) error {
+	cs := s.opts.CaseSensitive
+	runRows := max(s.opts.MemoryRows/len(s.inputs), 1)
+	sorted := make([]*sortedRows, len(s.inputs))
+	heads := make([]keyedRow, len(s.inputs))
+	live := make([]bool, len(s.inputs))
+	for i, in := range s.inputs {
+		rows, err := sortByKey(ctx, in.r, func(row []string) string { return in.key(row, cs) }, runRows, s.progress)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.path, err)
+		}
+		defer rows.close()
+		sorted[i] = rows
+		if heads[i], live[i], err = rows.next(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var key string
+		found := false
+		for i := range heads {
+			if live[i] && (!found || heads[i].key < key) {
+				key, found = heads[i].key, true
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		// Collect which inputs have this key, keeping the first row seen, then
+		// move every input past it.
+		pick, from, others := []string(nil), -1, 0
+		for i := range heads {
+			if !live[i] || heads[i].key != key {
+				continue
+			}
+			if from < 0 {
+				pick, from = heads[i].row, i
+			}
+			if i > 0 {
+				others++
+			}
+			for live[i] && heads[i].key == key {
+				var err error
+				if heads[i], live[i], err = sorted[i].next(); err != nil {
+					return err
+				}
+			}
+		}
+
+		switch {
+		case s.opts.Op == SetUnion:
+		case from != 0:
+			continue
+		case !s.keep(others > 0, others == len(s.inputs)-1):
+			continue
+		}
+		if err := s.write(from, pick); err != nil {
+			return err
+		}
+	}
+}
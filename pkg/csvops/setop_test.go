@@ -0,0 +1,87 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetOp(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	c := filepath.Join(dir, "c.csv")
+	writeCSV(t, a, "email,name\nx@a.io,X\nY@a.io,Y\nz@a.io,Z\nx@a.io,X2\n")
+	writeCSV(t, b, "name,email\nYy,y@a.io\nW,w@a.io\n")
+	writeCSV(t, c, "email\ny@a.io\nz@a.io\n")
+	p := filepath.Join(dir, "p.csv")
+	q := filepath.Join(dir, "q.csv")
+	writeCSV(t, p, "email,name\nx@a.io,\ny@a.io,Y\n")
+	writeCSV(t, q, "email\nx@a.io\nz@a.io\n") // no name column
+
+	cases := []struct {
+		name   string
+		op     SetOperation
+		inputs []string
+		keys   []string
+		cs     bool
+		hash   string // output in input order
+		sorted string // output in key order
+	}{
+		{"union", SetUnion, []string{a, b}, []string{"email"}, false,
+			"email,name\nx@a.io,X\nY@a.io,Y\nz@a.io,Z\nw@a.io,W\n",
+			"email,name\nw@a.io,W\nx@a.io,X\nY@a.io,Y\nz@a.io,Z\n"},
+		{"union case-sensitive", SetUnion, []string{a, b}, []string{"email"}, true,
+			"email,name\nx@a.io,X\nY@a.io,Y\nz@a.io,Z\ny@a.io,Yy\nw@a.io,W\n",
+			"email,name\nY@a.io,Y\nw@a.io,W\nx@a.io,X\ny@a.io,Yy\nz@a.io,Z\n"},
+		{"intersect", SetIntersect, []string{a, b, c}, []string{"email"}, false,
+			"email,name\nY@a.io,Y\n",
+			"email,name\nY@a.io,Y\n"},
+		{"except", SetExcept, []string{a, b}, []string{"email"}, false,
+			"email,name\nx@a.io,X\nz@a.io,Z\n",
+			"email,name\nx@a.io,X\nz@a.io,Z\n"},
+		{"except full row", SetExcept, []string{a, a}, nil, true,
+			"email,name\n",
+			"email,name\n"},
+		{"union full row, missing column", SetUnion, []string{p, q}, nil, true,
+			"email,name\nx@a.io,\ny@a.io,Y\nz@a.io,\n",
+			"email,name\nx@a.io,\ny@a.io,Y\nz@a.io,\n"},
+		{"intersect full row, missing column", SetIntersect, []string{p, q}, nil, false,
+			"email,name\nx@a.io,\n",
+			"email,name\nx@a.io,\n"},
+		{"except full row, missing column", SetExcept, []string{p, q}, nil, false,
+			"email,name\ny@a.io,Y\n",
+			"email,name\ny@a.io,Y\n"},
+	}
+	for _, tc := range cases {
+		for _, mem := range []int{0, 2} {
+			var buf bytes.Buffer
+			res, err := SetOp(context.Background(), SetOpOptions{
+				Op:            tc.op,
+				Inputs:        tc.inputs,
+				KeyColumns:    tc.keys,
+				CaseSensitive: tc.cs,
+				Output:        &buf,
+				MemoryRows:    mem,
+			})
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			want := tc.hash
+			if res.Strategy == DiffSortMerge {
+				want = tc.sorted
+			}
+			if buf.String() != want {
+				t.Errorf("%s (%s):\n%s\nwant:\n%s", tc.name, res.Strategy, buf.String(), want)
+			}
+		}
+	}
+}
+
+func TestSetOp_NeedsTwoInputs(t *testing.T) {
+	_, err := SetOp(context.Background(), SetOpOptions{Op: SetUnion, Inputs: []string{"a.csv"}, Output: &bytes.Buffer{}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
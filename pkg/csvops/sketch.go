@@ -0,0 +1,307 @@
+package csvops
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// kllSketch is a KLL quantile sketch (Karnin, Lang, Liberty 2016). It keeps
+// a hierarchy of compactors: level h holds items of weight 2^h, and a full
+// level is sorted and every other item promoted to the next one. Memory is
+// O(k) regardless of stream length and rank error is roughly 1.7/k; while
+// nothing has been compacted the answers are exact.
+type kllSketch struct {
+	k          int
+	compactors [][]float64
+	size       int
+	maxSize    int
+	n          int64
+	coin       uint64 // xorshift state; fixed seed keeps results reproducible
+}
+
+const defaultKLLK = 200
+
+func newKLLSketch(k int) *kllSketch {
+	s := &kllSketch{k: k, coin: 0x9e3779b97f4a7c15}
+	s.grow()
+	return s
+}
+
+// capacity of level h shrinks geometrically (factor 2/3) below the top level.
+func (s *kllSketch) capacity(h int) int {
+	depth := len(s.compactors) - h - 1
+	return int(math.Ceil(math.Pow(2.0/3.0, float64(depth))*float64(s.k))) + 1
+}
+
+func (s *kllSketch) grow() {
+	s.compactors = append(s.compactors, nil)
+	s.maxSize = 0
+	for h := range s.compactors {
+		s.maxSize += s.capacity(h)
+	}
+}
+
+func (s *kllSketch) add(v float64) {
+	s.compactors[0] = append(s.compactors[0], v)
+	s.size++
+	s.n++
+	if s.size >= s.maxSize {
+		s.compress()
+	}
+}
+
+func (s *kllSketch) flip() bool {
+	s.coin ^= s.coin << 13
+	s.coin ^= s.coin >> 7
+	s.coin ^= s.coin << 17
+	return s.coin&1 == 1
+}
+
+func (s *kllSketch) compress() {
+	for h := 0; h < len(s.compactors); h++ {
+		if len(s.compactors[h]) < s.capacity(h) {
+			continue
+		}
+		if h+1 >= len(s.compactors) {
+			s.grow()
+		}
+		level := s.compactors[h]
+		sort.Float64s(level)
+		// Promote every other item, starting at a random offset; an odd
+		// item out stays behind.
+		keep := len(level) % 2
+		start := keep
+		if s.flip() {
+			start++
+		}
+		for i := start; i < len(level); i += 2 {
+			s.compactors[h+1] = append(s.compactors[h+1], level[i])
+		}
+		if keep == 1 {
+			s.compactors[h] = append(level[:0], level[0])
+		} else {
+			s.compactors[h] = level[:0]
+		}
+		s.size = 0
+		for _, c := range s.compactors {
+			s.size += len(c)
+		}
+		return
+	}
+}
+
+// weighted returns the retained items with their weights, sorted by value.
+func (s *kllSketch) weighted() ([]float64, []int64) {
+	type item struct {
+		v float64
+		w int64
+	}
+	items := make([]item, 0, s.size)
+	for h, c := range s.compactors {
+		for _, v := range c {
+			items = append(items, item{v, 1 << h})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].v < items[j].v })
+	vals := make([]float64, len(items))
+	weights := make([]int64, len(items))
+	for i, it := range items {
+		vals[i], weights[i] = it.v, it.w
+	}
+	return vals, weights
+}
+
+// quantiles returns the approximate value at each rank q in [0, 1].
+func (s *kllSketch) quantiles(qs ...float64) []float64 {
+	out := make([]float64, len(qs))
+	vals, weights := s.weighted()
+	if len(vals) == 0 {
+		return out
+	}
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	for i, q := range qs {
+		target := q * float64(total)
+		var cum int64
+		out[i] = vals[len(vals)-1]
+		for j, w := range weights {
+			cum += w
+			if float64(cum) >= target {
+				out[i] = vals[j]
+				break
+			}
+		}
+	}
+	return out
+}
+
+// hllPrecision gives 2^14 one-byte registers (16 KiB per column) and a
+// relative standard error of 1.04/sqrt(2^14) ≈ 0.81%.
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct strings added to it
+// (Flajolet et al. 2007, with linear counting for small cardinalities).
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// hashString is FNV-1a followed by the splitmix64 finalizer, which spreads
+// FNV's weak high bits well enough for register selection.
+func hashString(v string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(v))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (h *hyperLogLog) add(v string) {
+	x := hashString(v)
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hyperLogLog) estimate() float64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return e
+}
+
+// relativeError is the estimator's relative standard error.
+func (h *hyperLogLog) relativeError() float64 {
+	return 1.04 / math.Sqrt(float64(len(h.registers)))
+}
+
+// spaceSaving tracks approximate heavy hitters in O(k) memory (Metwally et
+// al. 2005). Every value occurring more than n/k times is guaranteed to be
+// monitored, and a counter overestimates its value's count by at most err.
+type spaceSaving struct {
+	k     int
+	items map[string]*ssCounter
+	heap  ssHeap // min-heap on count
+}
+
+type ssCounter struct {
+	value string
+	count int
+	err   int
+	index int
+}
+
+type ssHeap []*ssCounter
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *ssHeap) Push(x any) {
+	c := x.(*ssCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+func (h *ssHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// defaultHeavyHitters is the number of counters kept per column once exact
+// counting stops.
+const defaultHeavyHitters = 1000
+
+func newSpaceSaving(k int) *spaceSaving {
+	return &spaceSaving{k: k, items: make(map[string]*ssCounter, k)}
+}
+
+// seed loads exact counts, keeping the k largest. Values left out all have
+// counts no higher than the smallest kept one, so the usual error bound holds
+// for everything added afterwards.
+func (s *spaceSaving) seed(counts map[string]int) {
+	all := make([]ValueCount, 0, len(counts))
+	for v, n := range counts {
+		all = append(all, ValueCount{Value: v, Count: n})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Count > all[j].Count })
+	for _, vc := range all[:min(len(all), s.k)] {
+		c := &ssCounter{value: vc.Value, count: vc.Count}
+		s.items[vc.Value] = c
+		heap.Push(&s.heap, c)
+	}
+}
+
+func (s *spaceSaving) add(v string) {
+	if c, ok := s.items[v]; ok {
+		c.count++
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.k {
+		c := &ssCounter{value: v, count: 1}
+		s.items[v] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+	// Evict the smallest counter; the newcomer inherits its count as error.
+	c := s.heap[0]
+	delete(s.items, c.value)
+	c.value, c.err = v, c.count
+	c.count++
+	s.items[v] = c
+	heap.Fix(&s.heap, 0)
+}
+
+// counts returns the monitored values with their (over)estimated counts.
+func (s *spaceSaving) counts() []ValueCount {
+	out := make([]ValueCount, 0, len(s.heap))
+	for _, c := range s.heap {
+		out = append(out, ValueCount{Value: c.value, Count: c.count, Error: c.err})
+	}
+	return out
+}
+
+// rankBelow returns the approximate fraction of added values strictly less
+// than x.
+func (s *kllSketch) rankBelow(x float64) float64 {
+	var below, total int64
+	for h, c := range s.compactors {
+		for _, v := range c {
+			total += 1 << h
+			if v < x {
+				below += 1 << h
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(below) / float64(total)
+}
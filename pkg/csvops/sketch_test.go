@@ -0,0 +1,40 @@
+package csvops
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestKLLSketch_QuantileAccuracy(t *testing.T) {
+	const n = 200000
+	s := newKLLSketch(defaultKLLK)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, i := range rng.Perm(n) {
+		s.add(float64(i))
+	}
+	if s.size > 2000 {
+		t.Errorf("sketch retains %d items, want O(k)", s.size)
+	}
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.95, 0.99} {
+		got := s.quantiles(q)[0]
+		// value i has rank i, so the rank error is |got - q*n| / n.
+		if err := (got - q*n) / n; err > 0.02 || err < -0.02 {
+			t.Errorf("q=%v: got %v, rank error %.4f", q, got, err)
+		}
+	}
+}
+
+func TestKLLSketch_ExactWhenSmall(t *testing.T) {
+	s := newKLLSketch(defaultKLLK)
+	for i := 1; i <= 100; i++ {
+		s.add(float64(i))
+	}
+	got := s.quantiles(0, 0.5, 0.95, 1)
+	want := []float64{1, 50, 95, 100}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("quantiles = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sliceString(t *testing.T, in string, opts SliceOptions) string {
+	t.Helper()
+	var buf bytes.Buffer
+	opts.Input, opts.Output = in, &buf
+	if _, err := Slice(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSlice_HeadRangeEvery(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n2\n3\n4\n5\n6\n")
+
+	tests := []struct {
+		name string
+		opts SliceOptions
+		want string
+	}{
+		{"head", SliceOptions{Head: 2}, "id\n1\n2\n"},
+		{"range", SliceOptions{From: 2, To: 4}, "id\n2\n3\n4\n"},
+		{"from only", SliceOptions{From: 5}, "id\n5\n6\n"},
+		{"every", SliceOptions{Every: 2}, "id\n1\n3\n5\n"},
+		{"range every", SliceOptions{From: 2, To: 6, Every: 3}, "id\n2\n5\n"},
+		{"no header", SliceOptions{Head: 2, NoHeader: true}, "id\n1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sliceString(t, in, tt.opts); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlice_TailHandlesQuotedNewlines(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id,note\n1,a\n2,\"multi\nline\"\n3,\"say \"\"hi\"\"\n\"\n4,d\n")
+
+	tests := map[int]string{
+		1: "id,note\n4,d\n",
+		2: "id,note\n3,\"say \"\"hi\"\"\n\"\n4,d\n",
+		3: "id,note\n2,\"multi\nline\"\n3,\"say \"\"hi\"\"\n\"\n4,d\n",
+		9: "id,note\n1,a\n2,\"multi\nline\"\n3,\"say \"\"hi\"\"\n\"\n4,d\n",
+	}
+	for n, want := range tests {
+		if got := sliceString(t, in, SliceOptions{Tail: n}); got != want {
+			t.Errorf("tail %d = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestSlice_TailAcrossChunksWithoutTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("id,pad\n")
+	pad := strings.Repeat("x", 1000)
+	for i := 1; i <= 500; i++ { // ~500 KB, several tailChunk reads
+		fmt.Fprintf(&b, "%d,%s\r\n", i, pad)
+		if i%100 == 0 {
+			b.WriteString("\n") // blank lines are skipped by encoding/csv
+		}
+	}
+	writeCSV(t, in, strings.TrimSuffix(b.String(), "\r\n\n"))
+
+	got := sliceString(t, in, SliceOptions{Tail: 150})
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 151 {
+		t.Fatalf("got %d lines, want 151", len(lines))
+	}
+	if !strings.HasPrefix(lines[1], "351,") || !strings.HasPrefix(lines[150], "500,") {
+		t.Errorf("tail window = %.10q ... %.10q", lines[1], lines[150])
+	}
+}
+
+func TestSlice_RowsWritten(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n2\n3\n")
+
+	var buf bytes.Buffer
+	res, err := Slice(context.Background(), SliceOptions{Input: in, Output: &buf, Tail: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsWritten != 2 {
+		t.Errorf("RowsWritten = %d, want 2", res.RowsWritten)
+	}
+}
+
+func TestSlice_Validation(t *testing.T) {
+	var buf bytes.Buffer
+	bad := []SliceOptions{
+		{Input: "x", Output: &buf, Head: 1, Tail: 1},
+		{Input: "x", Output: &buf, From: 5, To: 2},
+		{Input: "x", Output: &buf, Every: -1},
+		{Input: "x"},
+	}
+	for _, opts := range bad {
+		if _, err := Slice(context.Background(), opts); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package csvops
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // SplitOptions configures a Split operation.
@@ -17,24 +20,78 @@ type SplitOptions struct {
 	WithHeader  bool
 	Delimiter   rune
 	Progress    Progress
+	// PartProgress, if set, is called as each output file is started with the
+	// 1-based file number and an estimate of the total number of files (0 in
+	// partition mode, where it is not known up front).
+	PartProgress Progress
+
+	// Exactly one of RowsPerFile, MaxBytesPerFile, NumParts and PartitionBy
+	// selects how rows are distributed across output files.
+
+	// MaxBytesPerFile caps the encoded size of each part, header included.
+	// Records are never split across files; a single record larger than the
+	// cap is written to a part of its own.
+	MaxBytesPerFile int64
+	// NumParts splits the input into this many parts whose row counts differ
+	// by at most one. Requires a row-count pre-scan of the input.
+	NumParts int
+	// PartitionBy switches Split to partition mode: each row is routed to a
+	// file named after its values in these columns. The columns are found by
+	// name in the header, so it needs WithHeader.
+	PartitionBy []string
+	// Hive lays partitions out as col=value/data.csv instead of value.csv.
+	Hive bool
+	// MaxOpenFiles caps the partition files held open at once; the least
+	// recently written one is closed and later reopened for append. Default 64.
+	MaxOpenFiles int
+
+	// NameTemplate names output files; see splitNamer for the tokens. Defaults
+	// to "part_{n}.csv", or to the partition layout in partition mode, where
+	// it names the leaf file inside the partition directories.
+	NameTemplate string
+	// IfExists controls what happens when an output file already exists:
+	// replace (default), skip (leave it and drop its rows) or fail.
+	IfExists IfExistsAction
+	// Manifest writes manifest.json into OutputDir listing each file's path,
+	// row count, byte size and SHA-256. IfExists applies to it too, checked
+	// before any part is written.
+	Manifest bool
 }
 
 // SplitResult is returned from Split.
 type SplitResult struct {
-	RowsProcessed int64
-	FilesCreated  int
+	RowsProcessed int64 `json:"rows_processed"`
+	FilesCreated  int   `json:"files_created"`
+	// Partitions lists every file written in partition mode, sorted by path.
+	Partitions []PartitionInfo `json:"partitions,omitempty"`
+	// Files lists every output file in the order it was started.
+	Files []SplitFile `json:"files"`
 }
 
 // Split streams the CSV at opts.Input and writes chunks of RowsPerFile rows
-// into opts.OutputDir as part_1.csv, part_2.csv, ...
+// (or MaxBytesPerFile bytes, or 1/NumParts of the input) into opts.OutputDir
+// as part_1.csv, part_2.csv, ... When PartitionBy is set it instead writes one
+// file per distinct value combination.
 func Split(ctx context.Context, opts SplitOptions) (SplitResult, error) {
 	var res SplitResult
 
 	if opts.Input == "" {
 		return res, fmt.Errorf("input is required")
 	}
-	if opts.RowsPerFile <= 0 {
-		return res, fmt.Errorf("RowsPerFile must be > 0")
+	modes := 0
+	for _, set := range []bool{opts.RowsPerFile > 0, opts.MaxBytesPerFile > 0, opts.NumParts > 0, len(opts.PartitionBy) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes == 0 {
+		return res, fmt.Errorf("RowsPerFile must be > 0 (or set MaxBytesPerFile, NumParts or PartitionBy)")
+	}
+	if modes > 1 {
+		return res, fmt.Errorf("only one of RowsPerFile, MaxBytesPerFile, NumParts and PartitionBy may be set")
+	}
+	if len(opts.PartitionBy) > 0 && !opts.WithHeader {
+		return res, fmt.Errorf("PartitionBy needs WithHeader: partition columns are found by header name")
 	}
 	if opts.Delimiter == 0 {
 		opts.Delimiter = ','
@@ -42,15 +99,51 @@ func Split(ctx context.Context, opts SplitOptions) (SplitResult, error) {
 	if opts.OutputDir == "" {
 		opts.OutputDir = "."
 	}
+	if opts.IfExists == "" {
+		opts.IfExists = IfExistsReplace
+	}
+	switch opts.IfExists {
+	case IfExistsReplace, IfExistsSkip, IfExistsFail:
+	default:
+		return res, fmt.Errorf("IfExists must be one of: replace, skip, fail (got %q)", opts.IfExists)
+	}
+	if opts.NameTemplate == "" && len(opts.PartitionBy) == 0 {
+		opts.NameTemplate = DefaultSplitTemplate
+	}
+	if opts.NameTemplate != "" && !strings.Contains(opts.NameTemplate, "{n") &&
+		(len(opts.PartitionBy) == 0 || !opts.Hive && !strings.Contains(opts.NameTemplate, "{value}")) {
+		return res, fmt.Errorf("name template %q must contain {n} (or {value} when partitioning) so files don't collide", opts.NameTemplate)
+	}
+	start := time.Now()
+	namer, err := newSplitNamer(opts.NameTemplate, opts.Input, start)
+	if err != nil {
+		return res, err
+	}
 
 	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
 		return res, fmt.Errorf("create output dir: %w", err)
 	}
+	if opts.Manifest {
+		skip, err := checkIfExists(filepath.Join(opts.OutputDir, ManifestFile), opts.IfExists)
+		if err != nil {
+			return res, err
+		}
+		opts.Manifest = !skip
+	}
 
-	total, err := CountDataRows(opts.Input, opts.Delimiter)
+	records, err := countRecords(opts.Input, opts.Delimiter)
 	if err != nil {
 		return res, err
 	}
+	total := max(records-1, 0) // same as CountDataRows
+
+	if len(opts.PartitionBy) > 0 {
+		res, err := splitPartitioned(ctx, opts, total, namer)
+		if err == nil && opts.Manifest {
+			err = writeSplitManifest(opts.OutputDir, opts.Input, start, res.RowsProcessed, res.Files)
+		}
+		return res, err
+	}
 
 	f, err := os.Open(opts.Input)
 	if err != nil {
@@ -71,18 +164,60 @@ func Split(ctx context.Context, opts SplitOptions) (SplitResult, error) {
 		header = h
 	}
 
-	buf := make([][]string, 0, opts.RowsPerFile)
-	part := 1
+	dataRows := records
+	if opts.WithHeader && dataRows > 0 {
+		dataRows--
+	}
+
+	// rowsInPart is the row limit for the given 1-based part in the
+	// row-count modes; byte mode rotates on size instead.
+	rowsInPart := func(int) int64 { return int64(opts.RowsPerFile) }
+	estParts := int64(0)
+	switch {
+	case opts.NumParts > 0:
+		base, extra := dataRows/int64(opts.NumParts), dataRows%int64(opts.NumParts)
+		rowsInPart = func(part int) int64 {
+			if int64(part) <= extra {
+				return base + 1
+			}
+			return base
+		}
+		estParts = min(int64(opts.NumParts), dataRows)
+	case opts.RowsPerFile > 0:
+		estParts = (dataRows + int64(opts.RowsPerFile) - 1) / int64(opts.RowsPerFile)
+	}
+
+	var sizer *recordSizer
+	var headerBytes int64
+	if opts.MaxBytesPerFile > 0 {
+		sizer = newRecordSizer(opts.Delimiter)
+		if opts.WithHeader && len(header) > 0 {
+			headerBytes = sizer.size(header)
+		}
+		// A rough estimate for PartProgress; the real count depends on quoting.
+		if st, err := f.Stat(); err == nil {
+			estParts = st.Size()/max(opts.MaxBytesPerFile-headerBytes, 1) + 1
+		}
+	}
 
-	flush := func() error {
-		if len(buf) == 0 {
+	// Rows go straight into the open part, which is rotated on the boundary,
+	// so memory stays constant whatever the chunk size.
+	var cur *splitPart
+	defer func() {
+		if cur != nil {
+			cur.abort()
+		}
+	}()
+	rotate := func() error {
+		if cur == nil {
 			return nil
 		}
-		if err := writeSplitChunk(opts.OutputDir, part, header, buf, opts.Delimiter, opts.WithHeader); err != nil {
+		sf, err := cur.close()
+		cur = nil
+		if err != nil {
 			return err
 		}
-		part++
-		buf = buf[:0]
+		res.Files = append(res.Files, sf)
 		return nil
 	}
 
@@ -97,44 +232,152 @@ func Split(ctx context.Context, opts SplitOptions) (SplitResult, error) {
 		if err != nil {
 			return res, fmt.Errorf("read row: %w", err)
 		}
-		buf = append(buf, row)
+		var n int64
+		if sizer != nil {
+			n = sizer.size(row)
+			if cur != nil && cur.bytes+n > opts.MaxBytesPerFile {
+				if err := rotate(); err != nil {
+					return res, err
+				}
+			}
+		}
+		if cur == nil {
+			part := len(res.Files) + 1
+			path := filepath.Join(opts.OutputDir, namer.name(part, nil))
+			if cur, err = openSplitPart(path, header, opts); err != nil {
+				return res, err
+			}
+			cur.bytes = headerBytes
+			safeProgress(opts.PartProgress, int64(part), max(estParts, int64(part)))
+		}
+		if err := cur.write(row); err != nil {
+			return res, err
+		}
+		cur.bytes += n
 		res.RowsProcessed++
 		safeProgress(opts.Progress, res.RowsProcessed, total)
 
-		if len(buf) == opts.RowsPerFile {
-			if err := flush(); err != nil {
+		if sizer == nil && cur.rows >= rowsInPart(len(res.Files)+1) {
+			if err := rotate(); err != nil {
 				return res, err
 			}
 		}
 	}
-	if err := flush(); err != nil {
+	if err := rotate(); err != nil {
 		return res, err
 	}
-	res.FilesCreated = part - 1
+	res.FilesCreated = len(res.Files)
+	if opts.Manifest {
+		if err := writeSplitManifest(opts.OutputDir, opts.Input, start, res.RowsProcessed, res.Files); err != nil {
+			return res, err
+		}
+	}
 	return res, nil
 }
 
-func writeSplitChunk(dir string, part int, header []string, rows [][]string, delim rune, withHeader bool) error {
-	path := filepath.Join(dir, fmt.Sprintf("part_%d.csv", part))
-	f, err := os.Create(path)
+// splitPart is the output file Split is currently streaming rows into.
+type splitPart struct {
+	sf     SplitFile
+	f      *os.File // nil when the existing file is skipped
+	w      *csv.Writer
+	digest *fileDigest
+	rows   int64 // input rows routed to the part, skipped or not
+	bytes  int64 // encoded size so far, used by MaxBytesPerFile
+	opts   SplitOptions
+}
+
+// openSplitPart creates path (honoring opts.IfExists) and writes the header.
+func openSplitPart(path string, header []string, opts SplitOptions) (*splitPart, error) {
+	p := &splitPart{sf: SplitFile{Path: path}, digest: newFileDigest(), opts: opts}
+	skip, err := checkIfExists(path, opts.IfExists)
 	if err != nil {
-		return fmt.Errorf("create %s: %w", path, err)
+		return nil, err
+	}
+	if skip {
+		p.sf.Skipped = true
+		return p, nil
 	}
-	defer f.Close()
 
-	w := csv.NewWriter(f)
-	w.Comma = delim
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	p.f, err = os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", path, err)
+	}
+	p.w = csv.NewWriter(io.MultiWriter(p.f, p.digest))
+	p.w.Comma = opts.Delimiter
 
-	if withHeader && len(header) > 0 {
-		if err := w.Write(header); err != nil {
-			return err
+	if opts.WithHeader && len(header) > 0 {
+		if err := p.w.Write(header); err != nil {
+			p.abort()
+			return nil, fmt.Errorf("write %s: %w", path, err)
 		}
 	}
-	for _, row := range rows {
-		if err := w.Write(row); err != nil {
-			return err
+	return p, nil
+}
+
+func (p *splitPart) write(row []string) error {
+	p.rows++
+	if p.w == nil {
+		return nil
+	}
+	if err := p.w.Write(row); err != nil {
+		return fmt.Errorf("write %s: %w", p.sf.Path, err)
+	}
+	p.sf.Rows++
+	return nil
+}
+
+// close finishes the part and returns its manifest entry.
+func (p *splitPart) close() (SplitFile, error) {
+	if p.f == nil {
+		d, err := digestFile(p.sf.Path)
+		if err != nil {
+			return p.sf, err
+		}
+		if p.sf.Rows, err = existingRows(p.sf.Path, p.opts.Delimiter, p.opts.WithHeader); err != nil {
+			return p.sf, err
 		}
+		p.sf.Bytes, p.sf.SHA256 = d.n, d.sum()
+		return p.sf, nil
+	}
+	p.w.Flush()
+	if err := p.w.Error(); err != nil {
+		p.f.Close()
+		return p.sf, fmt.Errorf("write %s: %w", p.sf.Path, err)
+	}
+	if err := p.f.Close(); err != nil {
+		return p.sf, fmt.Errorf("close %s: %w", p.sf.Path, err)
 	}
-	w.Flush()
-	return w.Error()
+	p.sf.Bytes, p.sf.SHA256 = p.digest.n, p.digest.sum()
+	return p.sf, nil
+}
+
+// abort closes the part's file without reporting it, on error paths.
+func (p *splitPart) abort() {
+	if p.f != nil {
+		p.f.Close()
+	}
+}
+
+// recordSizer measures the encoded size of a record exactly as csv.Writer
+// would emit it, including quoting and the trailing newline.
+type recordSizer struct {
+	buf bytes.Buffer
+	w   *csv.Writer
+}
+
+func newRecordSizer(delim rune) *recordSizer {
+	s := &recordSizer{}
+	s.w = csv.NewWriter(&s.buf)
+	s.w.Comma = delim
+	return s
+}
+
+func (s *recordSizer) size(record []string) int64 {
+	s.buf.Reset()
+	_ = s.w.Write(record)
+	s.w.Flush()
+	return int64(s.buf.Len())
 }
@@ -2,8 +2,14 @@ package csvops
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -117,3 +123,437 @@ func TestSplit_ValidatesInputs(t *testing.T) {
 		t.Error("expected error for RowsPerFile=0")
 	}
 }
+
+func TestSplit_PartitionByColumn(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id,country\n1,EG\n2,US\n3,EG\n4,\n5,a/b\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:       in,
+		OutputDir:   out,
+		PartitionBy: []string{"country"},
+		WithHeader:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsProcessed != 5 || res.FilesCreated != 4 {
+		t.Errorf("res = %+v, want 5 rows / 4 files", res)
+	}
+
+	if got := readFile(t, filepath.Join(out, "EG.csv")); got != "id,country\n1,EG\n3,EG\n" {
+		t.Errorf("EG.csv = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "__empty__.csv")); got != "id,country\n4,\n" {
+		t.Errorf("__empty__.csv = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "a_b.csv")); got != "id,country\n5,a/b\n" {
+		t.Errorf("a_b.csv = %q", got)
+	}
+
+	rows := map[string]int64{}
+	for _, p := range res.Partitions {
+		rows[filepath.Base(p.Path)] = p.Rows
+	}
+	if rows["EG.csv"] != 2 || rows["US.csv"] != 1 {
+		t.Errorf("manifest rows = %v", rows)
+	}
+}
+
+func TestSplit_PartitionValuesSanitizingToSamePath(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id,k\n1,a/b\n2,a_b\n3,a/b\n4,.\n5,\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:        in,
+		OutputDir:    out,
+		PartitionBy:  []string{"k"},
+		WithHeader:   true,
+		MaxOpenFiles: 1,
+		Manifest:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(out, "a_b.csv")); got != "id,k\n1,a/b\n2,a_b\n3,a/b\n" {
+		t.Errorf("a_b.csv = %q", got)
+	}
+	if got := readFile(t, filepath.Join(out, "__empty__.csv")); got != "id,k\n4,.\n5,\n" {
+		t.Errorf("__empty__.csv = %q", got)
+	}
+	if res.FilesCreated != 2 || len(res.Partitions) != 2 {
+		t.Fatalf("res = %+v, want 2 files", res)
+	}
+	p := res.Partitions[1]
+	if p.Rows != 3 || !reflect.DeepEqual(p.Values, []string{"a/b"}) || !reflect.DeepEqual(p.MergedValues, [][]string{{"a_b"}}) {
+		t.Errorf("a_b partition = %+v", p)
+	}
+	var m SplitManifest
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(out, ManifestFile))), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 2 || !reflect.DeepEqual(m.Files[0].MergedValues, [][]string{{"a_b"}}) {
+		t.Errorf("manifest files = %+v", m.Files)
+	}
+}
+
+func TestSplit_PartitionHiveWithLRUEviction(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	// Alternating values with MaxOpenFiles=1 forces every write to evict and
+	// reopen, which must append rather than truncate.
+	writeCSV(t, in, "id,country,city\n1,EG,Cairo\n2,US,NYC\n3,EG,Cairo\n4,US,NYC\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:        in,
+		OutputDir:    out,
+		PartitionBy:  []string{"country", "city"},
+		Hive:         true,
+		WithHeader:   true,
+		MaxOpenFiles: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 2 {
+		t.Errorf("FilesCreated = %d, want 2", res.FilesCreated)
+	}
+	got := readFile(t, filepath.Join(out, "country=EG", "city=Cairo", "data.csv"))
+	if got != "id,country,city\n1,EG,Cairo\n3,EG,Cairo\n" {
+		t.Errorf("EG partition = %q", got)
+	}
+	if !reflect.DeepEqual(res.Partitions[0].Values, []string{"EG", "Cairo"}) {
+		t.Errorf("Partitions[0].Values = %v", res.Partitions[0].Values)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"EG":            "EG",
+		"":              "__empty__",
+		"..":            "__empty__",
+		"../etc/passwd": "_etc_passwd",
+		`C:\x`:          "C__x",
+		"a\tb":          "a_b",
+		" spaced. ":     "spaced",
+	}
+	for in, want := range tests {
+		if got := SanitizeFileName(in); got != want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSplit_MaxBytesPerFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	// header "id,v\n" is 5 bytes, each row "n,xxxx\n" is 7 bytes.
+	writeCSV(t, in, "id,v\n1,aaaa\n2,bbbb\n3,cccc\n4,\"a,\"\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:           in,
+		OutputDir:       out,
+		MaxBytesPerFile: 19, // header + 2 rows
+		WithHeader:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 2 {
+		t.Fatalf("FilesCreated = %d, want 2", res.FilesCreated)
+	}
+	if got := readFile(t, filepath.Join(out, "part_1.csv")); got != "id,v\n1,aaaa\n2,bbbb\n" {
+		t.Errorf("part_1.csv = %q", got)
+	}
+	// The quoted record must stay whole and count its quotes toward the size.
+	if got := readFile(t, filepath.Join(out, "part_2.csv")); got != "id,v\n3,cccc\n4,\"a,\"\n" {
+		t.Errorf("part_2.csv = %q", got)
+	}
+}
+
+func TestSplit_MaxBytesOversizedRecordGetsOwnPart(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "1\n22222222222\n3\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:           in,
+		OutputDir:       out,
+		MaxBytesPerFile: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 3 || res.RowsProcessed != 3 {
+		t.Errorf("res = %+v, want 3 files / 3 rows", res)
+	}
+}
+
+func TestSplit_NumPartsEvenDistribution(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id\n1\n2\n3\n4\n5\n6\n7\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:      in,
+		OutputDir:  out,
+		NumParts:   3,
+		WithHeader: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 3 {
+		t.Fatalf("FilesCreated = %d, want 3", res.FilesCreated)
+	}
+	want := []string{"id\n1\n2\n3\n", "id\n4\n5\n", "id\n6\n7\n"}
+	for i, w := range want {
+		got := readFile(t, filepath.Join(out, fmt.Sprintf("part_%d.csv", i+1)))
+		if got != w {
+			t.Errorf("part_%d.csv = %q, want %q", i+1, got, w)
+		}
+	}
+}
+
+func TestSplit_NumPartsMoreThanRows(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "1\n2\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:     in,
+		OutputDir: filepath.Join(dir, "parts"),
+		NumParts:  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 2 {
+		t.Errorf("FilesCreated = %d, want 2 (no empty parts)", res.FilesCreated)
+	}
+}
+
+func TestSplit_RejectsMultipleModes(t *testing.T) {
+	_, err := Split(context.Background(), SplitOptions{Input: "x", RowsPerFile: 10, NumParts: 2})
+	if err == nil {
+		t.Error("expected error when two split modes are set")
+	}
+	_, err = Split(context.Background(), SplitOptions{Input: "x", PartitionBy: []string{"k"}})
+	if err == nil {
+		t.Error("expected error for PartitionBy without WithHeader")
+	}
+}
+
+func TestSplit_NameTemplate(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "users.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id\n1\n2\n3\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:        in,
+		OutputDir:    out,
+		RowsPerFile:  2,
+		WithHeader:   true,
+		NameTemplate: "{stem}_{n:04}.csv",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"users_0001.csv", "users_0002.csv"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+	if res.Files[1].Path != filepath.Join(out, "users_0002.csv") || res.Files[1].Rows != 1 {
+		t.Errorf("Files[1] = %+v", res.Files[1])
+	}
+}
+
+func TestSplit_NameTemplateValidation(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n")
+
+	for _, tmpl := range []string{"fixed.csv", "{n}_{bogus}.csv", "{n:xx}.csv"} {
+		_, err := Split(context.Background(), SplitOptions{
+			Input: in, OutputDir: dir, RowsPerFile: 1, NameTemplate: tmpl,
+		})
+		if err == nil {
+			t.Errorf("template %q: expected error", tmpl)
+		}
+	}
+}
+
+func TestSplit_IfExistsFailAndSkip(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id\n1\n2\n")
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeCSV(t, filepath.Join(out, "part_1.csv"), "keep me\n")
+
+	_, err := Split(context.Background(), SplitOptions{
+		Input: in, OutputDir: out, RowsPerFile: 1, WithHeader: true, IfExists: IfExistsFail,
+	})
+	if err == nil {
+		t.Fatal("expected error with IfExistsFail")
+	}
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input: in, OutputDir: out, RowsPerFile: 1, WithHeader: true, IfExists: IfExistsSkip,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(out, "part_1.csv")); got != "keep me\n" {
+		t.Errorf("skipped file was modified: %q", got)
+	}
+	if !res.Files[0].Skipped || res.Files[1].Skipped {
+		t.Errorf("Skipped flags = %v/%v, want true/false", res.Files[0].Skipped, res.Files[1].Skipped)
+	}
+	// The skipped entry describes the file on disk: a header and no rows.
+	if f := res.Files[0]; f.Rows != 0 || f.Bytes != int64(len("keep me\n")) {
+		t.Errorf("skipped entry = %+v, want 0 rows / 8 bytes", f)
+	}
+	if got := readFile(t, filepath.Join(out, "part_2.csv")); got != "id\n2\n" {
+		t.Errorf("part_2.csv = %q", got)
+	}
+
+	_, err = Split(context.Background(), SplitOptions{
+		Input: in, OutputDir: out, RowsPerFile: 1, IfExists: IfExistsAppend,
+	})
+	if err == nil {
+		t.Error("expected error for IfExistsAppend")
+	}
+}
+
+func TestSplit_IfExistsAppliesToManifest(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id\n1\n2\n")
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(out, ManifestFile)
+	writeCSV(t, manifest, "{}\n")
+
+	_, err := Split(context.Background(), SplitOptions{
+		Input: in, OutputDir: out, RowsPerFile: 1, WithHeader: true, Manifest: true, IfExists: IfExistsFail,
+	})
+	if err == nil {
+		t.Fatal("expected error with IfExistsFail")
+	}
+	if _, err := os.Stat(filepath.Join(out, "part_1.csv")); !os.IsNotExist(err) {
+		t.Errorf("part_1.csv was written before failing: %v", err)
+	}
+
+	if _, err := Split(context.Background(), SplitOptions{
+		Input: in, OutputDir: out, RowsPerFile: 1, WithHeader: true, Manifest: true, IfExists: IfExistsSkip,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, manifest); got != "{}\n" {
+		t.Errorf("skipped manifest was modified: %q", got)
+	}
+}
+
+func TestSplit_ManifestMatchesFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "parts")
+	writeCSV(t, in, "id,country\n1,EG\n2,US\n3,EG\n")
+
+	res, err := Split(context.Background(), SplitOptions{
+		Input:       in,
+		OutputDir:   out,
+		PartitionBy: []string{"country"},
+		Hive:        true,
+		WithHeader:  true,
+		Manifest:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m SplitManifest
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(out, ManifestFile))), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows != 3 || len(m.Files) != 2 {
+		t.Fatalf("manifest rows=%d files=%d, want 3/2", m.Rows, len(m.Files))
+	}
+	for i, f := range m.Files {
+		if f.Path != path.Join("country="+res.Files[i].Values[0], "data.csv") {
+			t.Errorf("manifest path = %q", f.Path)
+		}
+		body := readFile(t, filepath.Join(out, filepath.FromSlash(f.Path)))
+		sum := sha256.Sum256([]byte(body))
+		if f.SHA256 != hex.EncodeToString(sum[:]) || f.Bytes != int64(len(body)) {
+			t.Errorf("%s: digest/size mismatch: %+v", f.Path, f)
+		}
+	}
+	if m.Files[0].Rows != 2 {
+		t.Errorf("EG rows = %d, want 2", m.Files[0].Rows)
+	}
+}
+
+func TestSplit_PartProgress(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\n1\n2\n3\n4\n5\n")
+
+	var calls [][2]int64
+	_, err := Split(context.Background(), SplitOptions{
+		Input:       in,
+		OutputDir:   filepath.Join(dir, "out"),
+		RowsPerFile: 2,
+		WithHeader:  true,
+		PartProgress: func(part, parts int64) {
+			calls = append(calls, [2]int64{part, parts})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int64{{1, 3}, {2, 3}, {3, 3}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("PartProgress calls = %v, want %v", calls, want)
+	}
+}
+
+func TestSplit_StreamsLargeChunkWithoutBuffering(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "out")
+	writeCSV(t, in, "id\n1\n2\n3\n")
+
+	// A chunk size far larger than the input must not pre-allocate or
+	// otherwise depend on it: one file with every row.
+	res, err := Split(context.Background(), SplitOptions{
+		Input:       in,
+		OutputDir:   out,
+		RowsPerFile: 1 << 30,
+		WithHeader:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FilesCreated != 1 {
+		t.Errorf("FilesCreated = %d, want 1", res.FilesCreated)
+	}
+	if got := readFile(t, filepath.Join(out, "part_1.csv")); got != "id\n1\n2\n3\n" {
+		t.Errorf("part_1.csv = %q", got)
+	}
+}
@@ -36,9 +36,9 @@ type ToSQLiteOptions struct {
 
 // ToSQLiteResult is returned from ToSQLite.
 type ToSQLiteResult struct {
-	Table        string
-	RowsImported int64
-	Skipped      bool // true when IfExistsSkip was honored
+	Table        string `json:"table"`
+	RowsImported int64  `json:"rows_imported"`
+	Skipped      bool   `json:"skipped"` // true when IfExistsSkip was honored
 }
 
 var identSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_]`)
@@ -0,0 +1,435 @@
+package csvops
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ToSQLiteManyOptions configures a ToSQLiteMany operation.
+type ToSQLiteManyOptions struct {
+	// InputDir, Recursive, InputFiles and Patterns choose the input files as
+	// they do for Merge.
+	InputDir   string
+	Recursive  bool
+	InputFiles []string
+	Patterns   []string
+	DBPath     string
+	// Table, when set, loads every file into this one table, which gets the
+	// union of the files' columns plus SourceColumn holding each row's input
+	// path. Otherwise each file gets its own table named by
+	// SanitizeTableName; files with the same name share one.
+	Table        string
+	SourceColumn string // default SourceFileColumn
+	// IfExists applies to tables that existed before the import, including
+	// those SchemaFile declares (default IfExistsReplace).
+	IfExists IfExistsAction
+	// SchemaFile holds DDL applied before any rows are loaded, typically
+	// CREATE TABLE statements with types, FOREIGN KEY and CHECK constraints,
+	// plus CREATE INDEX. Tables it declares are loaded as they are, matching
+	// CSV columns by name; other tables are created with TEXT columns. A
+	// declared table that already exists is dropped and recreated from the
+	// schema (replace), kept with its existing definition (skip, append), or
+	// an error (fail), so an import can be re-run against the same database.
+	// Foreign keys are not enforced while loading, so files can come in any
+	// order; they are checked once at the end instead.
+	SchemaFile string
+	Delimiter  rune
+	// SkipErrors records a file that fails in its result and moves on to the
+	// next; otherwise the import stops at the first failure. Either way,
+	// files loaded before the failure stay committed.
+	SkipErrors bool
+	OnWarn     func(file string, err error)
+	Progress   Progress
+}
+
+// ToSQLiteFileResult reports one input file of a ToSQLiteMany import.
+type ToSQLiteFileResult struct {
+	Path         string `json:"path"`
+	Table        string `json:"table"`
+	RowsImported int64  `json:"rows_imported"`
+	Skipped      bool   `json:"skipped"`         // the table existed and IfExistsSkip was honored
+	Error        string `json:"error,omitempty"` // set when SkipErrors skipped the file
+}
+
+// ToSQLiteManyResult is returned from ToSQLiteMany.
+type ToSQLiteManyResult struct {
+	Files        []ToSQLiteFileResult `json:"files"`
+	Tables       []string             `json:"tables"` // in the order they were loaded
+	RowsImported int64                `json:"rows_imported"`
+	// ForeignKeyViolations counts rows whose foreign keys (from SchemaFile)
+	// don't match a parent row, as reported by PRAGMA foreign_key_check.
+	ForeignKeyViolations int64 `json:"foreign_key_violations"`
+}
+
+// ToSQLiteMany imports many CSV files into one SQLite database over a single
+// connection, each file in its own transaction.
+func ToSQLiteMany(ctx context.Context, opts ToSQLiteManyOptions) (ToSQLiteManyResult, error) {
+	var res ToSQLiteManyResult
+
+	if opts.DBPath == "" {
+		return res, fmt.Errorf("DBPath is required")
+	}
+	if opts.IfExists == "" {
+		opts.IfExists = IfExistsReplace
+	}
+	switch opts.IfExists {
+	case IfExistsReplace, IfExistsSkip, IfExistsAppend, IfExistsFail:
+	default:
+		return res, fmt.Errorf("IfExists must be one of: replace, skip, append, fail (got %q)", opts.IfExists)
+	}
+	if opts.SourceColumn == "" {
+		opts.SourceColumn = SourceFileColumn
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	paths, err := listInputs(opts.InputDir, opts.Recursive, opts.InputFiles, opts.Patterns)
+	if err != nil {
+		return res, err
+	}
+	if len(paths) == 0 {
+		return res, fmt.Errorf("no input files found")
+	}
+	var schema string
+	if opts.SchemaFile != "" {
+		b, err := os.ReadFile(opts.SchemaFile)
+		if err != nil {
+			return res, fmt.Errorf("read schema: %w", err)
+		}
+		schema = string(b)
+	}
+
+	// fail records a failed file and says whether to go on.
+	fail := func(fr *ToSQLiteFileResult, err error) error {
+		if !opts.SkipErrors {
+			return fmt.Errorf("%s: %w", fr.Path, err)
+		}
+		fr.Error = err.Error()
+		if opts.OnWarn != nil {
+			opts.OnWarn(fr.Path, err)
+		}
+		return nil
+	}
+
+	// Read every header and count rows up front, for the single table's
+	// columns and the progress total.
+	files := make([]*sqliteImportFile, 0, len(paths))
+	var total int64
+	for _, p := range paths {
+		f := &sqliteImportFile{ToSQLiteFileResult: ToSQLiteFileResult{Path: p, Table: opts.Table}}
+		if f.Table == "" {
+			f.Table = SanitizeTableName(p)
+		}
+		f.header, f.rows, err = readHeaderAndCount(ctx, p, opts.Delimiter)
+		if err != nil {
+			if err := fail(&f.ToSQLiteFileResult, err); err != nil {
+				return res, err
+			}
+			res.Files = append(res.Files, f.ToSQLiteFileResult)
+			continue
+		}
+		total += f.rows
+		files = append(files, f)
+	}
+
+	dbPath, _ := filepath.Abs(opts.DBPath)
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return res, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return res, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer conn.Close()
+
+	existing, err := sqliteTableSet(ctx, conn)
+	if err != nil {
+		return res, err
+	}
+	fromSchema := map[string]bool{}
+	if schema != "" {
+		if fromSchema, err = applySchema(ctx, conn, schema, opts.IfExists, existing); err != nil {
+			return res, err
+		}
+	}
+
+	// ready tracks tables prepared by this import: true to load into, false
+	// to skip.
+	ready := map[string]bool{}
+	prepare := func(f *sqliteImportFile) (bool, error) {
+		if load, ok := ready[f.Table]; ok {
+			return load, nil
+		}
+		load := true
+		create := !fromSchema[f.Table]
+		if existing[f.Table] {
+			switch opts.IfExists {
+			case IfExistsFail:
+				return false, fmt.Errorf("table %q already exists", f.Table)
+			case IfExistsSkip:
+				load, create = false, false
+			case IfExistsAppend:
+				create = false
+			case IfExistsReplace:
+				if _, err := conn.ExecContext(ctx, "DROP TABLE "+QuoteIdent(f.Table)); err != nil {
+					return false, fmt.Errorf("drop existing table: %w", err)
+				}
+			}
+		}
+		if create {
+			columns := f.header
+			if opts.Table != "" {
+				columns = nil
+				for _, g := range files {
+					columns = append(columns, notIn(g.header, columns)...)
+				}
+				columns = append(columns, opts.SourceColumn)
+			}
+			cols := make([]string, len(columns))
+			for i, c := range columns {
+				cols[i] = QuoteIdent(c) + " TEXT"
+			}
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", QuoteIdent(f.Table), strings.Join(cols, ", "))); err != nil {
+				return false, fmt.Errorf("create table: %w", err)
+			}
+		}
+		ready[f.Table] = load
+		if load {
+			res.Tables = append(res.Tables, f.Table)
+		}
+		return load, nil
+	}
+
+	var done int64
+	for _, f := range files {
+		load, err := prepare(f)
+		if err == nil && load {
+			err = f.load(ctx, conn, opts, func() {
+				done++
+				safeProgress(opts.Progress, done, total)
+			})
+		}
+		f.Skipped = !load && err == nil
+		if err != nil {
+			if err := fail(&f.ToSQLiteFileResult, err); err != nil {
+				return res, err
+			}
+		}
+		res.RowsImported += f.RowsImported
+		res.Files = append(res.Files, f.ToSQLiteFileResult)
+	}
+
+	if schema != "" {
+		rows, err := conn.QueryContext(ctx, "PRAGMA foreign_key_check")
+		if err != nil {
+			return res, fmt.Errorf("check foreign keys: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			res.ForeignKeyViolations++
+		}
+		if err := rows.Err(); err != nil {
+			return res, fmt.Errorf("check foreign keys: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// applySchema applies the DDL in schema to conn and returns the tables it
+// declares. The schema is first run in a scratch in-memory database to learn
+// what it creates; IfExists is then applied to declared tables that already
+// exist, and the recorded DDL is replayed for the rest. Replaced tables are
+// removed from existing.
+func applySchema(ctx context.Context, conn *sql.Conn, schema string, ifExists IfExistsAction, existing map[string]bool) (map[string]bool, error) {
+	scratch, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer scratch.Close()
+	sc, err := scratch.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer sc.Close()
+	if _, err := sc.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("run schema: %w", err)
+	}
+
+	type object struct{ typ, name, table, sql string }
+	var objects []object
+	rows, err := sc.QueryContext(ctx, "SELECT type, name, tbl_name, sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.typ, &o.name, &o.table, &o.sql); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+
+	declared := map[string]bool{}
+	kept := map[string]bool{}
+	for _, o := range objects {
+		if o.typ != "table" {
+			continue
+		}
+		declared[o.name] = true
+		if !existing[o.name] {
+			continue
+		}
+		switch ifExists {
+		case IfExistsFail:
+			return nil, fmt.Errorf("table %q already exists", o.name)
+		case IfExistsSkip, IfExistsAppend:
+			kept[o.name] = true
+		case IfExistsReplace:
+			if _, err := conn.ExecContext(ctx, "DROP TABLE "+QuoteIdent(o.name)); err != nil {
+				return nil, fmt.Errorf("drop existing table: %w", err)
+			}
+			delete(existing, o.name)
+		}
+	}
+
+	// Kept tables keep their own indexes and triggers too; other indexes,
+	// triggers and views left from an earlier run are kept as well.
+	for _, o := range objects {
+		if kept[o.table] {
+			continue
+		}
+		if o.typ != "table" {
+			var n int
+			if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE name = ?", o.name).Scan(&n); err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				continue
+			}
+		}
+		if _, err := conn.ExecContext(ctx, o.sql); err != nil {
+			return nil, fmt.Errorf("run schema: %s %q: %w", o.typ, o.name, err)
+		}
+	}
+	return declared, nil
+}
+
+// sqliteImportFile is one input of ToSQLiteMany.
+type sqliteImportFile struct {
+	ToSQLiteFileResult
+	header []string
+	rows   int64 // data rows, for progress
+}
+
+// load inserts the file's rows by column name in one transaction. In
+// single-table mode the path goes into opts.SourceColumn.
+func (f *sqliteImportFile) load(ctx context.Context, conn *sql.Conn, opts ToSQLiteManyOptions, step func()) error {
+	in, err := os.Open(f.Path)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer in.Close()
+	r := csv.NewReader(in)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+	if _, err := r.Read(); err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	columns := f.header
+	if opts.Table != "" {
+		columns = append(append([]string{}, columns...), opts.SourceColumn)
+	}
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = QuoteIdent(c)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(columns)), ",")
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", QuoteIdent(f.Table), strings.Join(cols, ", "), placeholders)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	vals := make([]any, len(columns))
+	if opts.Table != "" {
+		vals[len(vals)-1] = f.Path
+	}
+	var n int64
+	for {
+		if err := ctx.Err(); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("read row: %w", err)
+		}
+		for i := range f.header {
+			vals[i] = cell(rec, i)
+		}
+		if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert row: %w", err)
+		}
+		n++
+		step()
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	f.RowsImported = n
+	return nil
+}
+
+// readHeaderAndCount returns the header of a CSV file and its number of data
+// rows.
+func readHeaderAndCount(ctx context.Context, path string, delim rune) ([]string, int64, error) {
+	var header []string
+	var n int64
+	err := readRows(ctx, path, delim, func(h []string) error {
+		header = h
+		return nil
+	}, func([]string) error {
+		n++
+		return nil
+	})
+	return header, n, err
+}
+
+// sqliteTableSet returns the names of the user tables in a database.
+func sqliteTableSet(ctx context.Context, q sqlQueryer) (map[string]bool, error) {
+	names, err := sqliteTables(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set, nil
+}
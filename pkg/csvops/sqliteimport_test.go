@@ -0,0 +1,200 @@
+package csvops
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToSQLiteMany_TablePerFile(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, filepath.Join(dir, "users.csv"), "id,name\n1,a\n2,b\n")
+	writeCSV(t, filepath.Join(dir, "orders.csv"), "id,user_id\n10,1\n")
+	writeCSV(t, filepath.Join(dir, "notes.txt"), "ignored\n")
+	db := filepath.Join(dir, "out.db")
+
+	res, err := ToSQLiteMany(context.Background(), ToSQLiteManyOptions{InputDir: dir, DBPath: db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 2 || res.RowsImported != 3 {
+		t.Fatalf("res = %+v", res)
+	}
+	if queryCount(t, db, "users") != 2 || queryCount(t, db, "orders") != 1 {
+		t.Error("table row counts mismatch")
+	}
+
+	// A second run replaces the tables by default, and skips them on request.
+	res, err = ToSQLiteMany(context.Background(), ToSQLiteManyOptions{InputDir: dir, DBPath: db, IfExists: IfExistsSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Files[0].Skipped || res.RowsImported != 0 || queryCount(t, db, "users") != 2 {
+		t.Errorf("skip run: %+v", res)
+	}
+}
+
+func TestToSQLiteMany_SingleTable(t *testing.T) {
+	dir := t.TempDir()
+	jan := filepath.Join(dir, "jan.csv")
+	feb := filepath.Join(dir, "feb.csv")
+	writeCSV(t, jan, "id,amount\n1,5\n")
+	writeCSV(t, feb, "id,amount,note\n2,7,late\n3,1\n")
+	db := filepath.Join(dir, "out.db")
+
+	res, err := ToSQLiteMany(context.Background(), ToSQLiteManyOptions{InputFiles: []string{jan, feb}, DBPath: db, Table: "sales"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsImported != 3 || len(res.Tables) != 1 {
+		t.Fatalf("res = %+v", res)
+	}
+
+	conn, err := sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var note sql.NullString
+	var src string
+	if err := conn.QueryRow(`SELECT note, _source_file FROM sales WHERE id = '1'`).Scan(&note, &src); err != nil {
+		t.Fatal(err)
+	}
+	if note.Valid || src != jan {
+		t.Errorf("row 1: note = %v, source = %q", note, src)
+	}
+	if err := conn.QueryRow(`SELECT note, _source_file FROM sales WHERE id = '3'`).Scan(&note, &src); err != nil {
+		t.Fatal(err)
+	}
+	if note.String != "" || src != feb {
+		t.Errorf("row 3: note = %v, source = %q", note, src)
+	}
+}
+
+func TestToSQLiteMany_Schema(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.csv")
+	orders := filepath.Join(dir, "orders.csv")
+	writeCSV(t, users, "id,name\n1,a\n")
+	writeCSV(t, orders, "id,user_id,total\n10,1,2.5\n11,9,1\n")
+	schema := filepath.Join(dir, "schema.sql")
+	if err := os.WriteFile(schema, []byte(`
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL);
+CREATE INDEX orders_user ON orders(user_id);
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(dir, "out.db")
+
+	// orders loads before users: foreign keys are only checked at the end.
+	res, err := ToSQLiteMany(context.Background(), ToSQLiteManyOptions{InputFiles: []string{orders, users}, DBPath: db, SchemaFile: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsImported != 3 || res.ForeignKeyViolations != 1 {
+		t.Errorf("res = %+v", res)
+	}
+
+	conn, err := sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var total float64
+	if err := conn.QueryRow(`SELECT sum(total) FROM orders`).Scan(&total); err != nil {
+		t.Fatal(err)
+	}
+	if total != 3.5 {
+		t.Errorf("sum(total) = %v, want typed REAL column", total)
+	}
+}
+
+func TestToSQLiteMany_SkipErrors(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.csv")
+	bad := filepath.Join(dir, "bad.csv")
+	writeCSV(t, good, "id\n1\n")
+	writeCSV(t, bad, "id\n\"unterminated\n")
+	db := filepath.Join(dir, "out.db")
+
+	if _, err := ToSQLiteMany(context.Background(), ToSQLiteManyOptions{InputFiles: []string{bad, good}, DBPath: db}); err == nil {
+		t.Fatal("expected error without SkipErrors")
+	}
+	var warned []string
+	res, err := ToSQLiteMany(context.Background(), ToSQLiteManyOptions{
+		InputFiles: []string{bad, good},
+		DBPath:     db,
+		SkipErrors: true,
+		OnWarn:     func(file string, err error) { warned = append(warned, file) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warned) != 1 || res.Files[0].Error == "" || res.RowsImported != 1 {
+		t.Errorf("res = %+v, warned = %v", res, warned)
+	}
+}
+
+func TestToSQLiteMany_SchemaRerun(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.csv")
+	orders := filepath.Join(dir, "orders.csv")
+	writeCSV(t, users, "id,name\n1,a\n")
+	writeCSV(t, orders, "id,user_id\n10,1\n11,9\n")
+	db := filepath.Join(dir, "out.db")
+
+	for _, ddl := range []string{"CREATE TABLE", "CREATE TABLE IF NOT EXISTS"} {
+		schema := filepath.Join(dir, "schema.sql")
+		if err := os.WriteFile(schema, []byte(ddl+` users (id INTEGER PRIMARY KEY, name TEXT);
+`+ddl+` orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+CREATE INDEX IF NOT EXISTS orders_user ON orders(user_id);
+`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		opts := ToSQLiteManyOptions{InputFiles: []string{users, orders}, DBPath: db, SchemaFile: schema}
+
+		for run := 1; run <= 2; run++ {
+			res, err := ToSQLiteMany(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("%s, run %d: %v", ddl, run, err)
+			}
+			if res.RowsImported != 3 || res.ForeignKeyViolations != 1 {
+				t.Errorf("%s, run %d: res = %+v", ddl, run, res)
+			}
+			if queryCount(t, db, "orders") != 2 {
+				t.Errorf("%s, run %d: orders not replaced", ddl, run)
+			}
+		}
+
+		conn, err := sql.Open("sqlite", db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var typ string
+		if err := conn.QueryRow(`SELECT typeof(id) FROM orders LIMIT 1`).Scan(&typ); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+		if typ != "integer" {
+			t.Errorf("%s: orders.id stored as %s, want the schema's INTEGER", ddl, typ)
+		}
+
+		opts.IfExists = IfExistsFail
+		if _, err := ToSQLiteMany(context.Background(), opts); err == nil {
+			t.Errorf("%s: expected error with IfExistsFail", ddl)
+		}
+		opts.IfExists = IfExistsSkip
+		if res, err := ToSQLiteMany(context.Background(), opts); err != nil || res.RowsImported != 0 || !res.Files[1].Skipped {
+			t.Errorf("%s: skip: err = %v, res = %+v", ddl, err, res)
+		}
+		// Append keeps the existing tables' primary keys, so the same ids clash.
+		opts.IfExists = IfExistsAppend
+		if _, err := ToSQLiteMany(context.Background(), opts); err == nil {
+			t.Errorf("%s: append: expected a primary key violation", ddl)
+		}
+		os.Remove(db)
+	}
+}
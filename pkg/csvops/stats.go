@@ -5,64 +5,158 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 // StatsOptions configures a Stats operation.
 type StatsOptions struct {
 	Input string
-	// MaxUnique caps the number of distinct values tracked per column.
-	// 0 means unlimited. When a column hits the cap, new values are not
-	// recorded but existing values' counts continue to increment and the
-	// column is flagged as UniqueCapped.
+	// MaxUnique caps the number of distinct values counted exactly per
+	// column. 0 means unlimited. When a column hits the cap it is flagged as
+	// UniqueCapped and switches to sketches: Unique becomes a HyperLogLog
+	// estimate and Top comes from a Space-Saving heavy-hitters summary, so
+	// memory stays bounded on ID-like columns.
 	MaxUnique int
+	// EstimateUnique uses the sketches from the first row instead of exact
+	// counting.
+	EstimateUnique bool
+	// TopN is how many of the most frequent values to report per column
+	// (default DefaultTopN). Ties are broken by value, ascending.
+	TopN int
+	// Histogram selects the numeric bin layout (default HistogramEqualWidth)
+	// and Bins the number of bins (default DefaultHistogramBins).
+	Histogram HistogramKind
+	Bins      int
+	// Patterns reduces every value to its shape (see ValueShape) and detects
+	// semantic types (email, URL, UUID, IP, ISO date, currency, phone),
+	// reporting them in ColumnStats.Patterns and ColumnStats.SemanticTypes.
+	Patterns  bool
 	Delimiter rune
 	Progress  Progress
 }
 
+// DefaultTopN is used when StatsOptions.TopN is unset.
+const DefaultTopN = 3
+
 // ValueCount is a single (value, occurrence count) pair.
 type ValueCount struct {
-	Value string
-	Count int
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	// Error is how much Count may overstate the true count when it comes from
+	// the heavy-hitters sketch; 0 means exact.
+	Error int `json:"error,omitempty"`
 }
 
 // ColumnStats is the per-column summary.
 type ColumnStats struct {
-	Name         string
-	Unique       int  // number of distinct non-empty values seen (bounded by MaxUnique)
-	UniqueCapped bool // true if MaxUnique was reached
-	Empty        int
-	Top          []ValueCount // top N by count (N=3)
+	Name         string `json:"name"`
+	Unique       int    `json:"unique"`        // number of distinct non-empty values (estimated if UniqueEstimated)
+	UniqueCapped bool   `json:"unique_capped"` // true if MaxUnique was reached
+	// UniqueEstimated is set when Unique comes from HyperLogLog; UniqueError
+	// is then its relative standard error (e.g. 0.0081 for ±0.81%).
+	UniqueEstimated bool         `json:"unique_estimated"`
+	UniqueError     float64      `json:"unique_error,omitempty"`
+	Empty           int          `json:"empty"`
+	Top             []ValueCount `json:"top"`        // top TopN by count, then value
+	MinLength       int          `json:"min_length"` // shortest non-empty value, in characters
+	MaxLength       int          `json:"max_length"` // longest non-empty value, in characters
+	// Numeric is set when every non-empty value parses as a finite number.
+	Numeric *NumericStats `json:"numeric,omitempty"`
+	// LengthHistogram counts non-empty values by length in characters.
+	LengthHistogram []HistogramBin `json:"length_histogram"`
+	// ClassHistogram counts non-empty values by the character classes they
+	// contain (e.g. "digits", "letters+digits"), largest first.
+	ClassHistogram []HistogramBin `json:"class_histogram"`
+	// Patterns holds the TopN most common value shapes and SemanticTypes
+	// every recognized semantic type, both with their share of the non-empty
+	// values. Only set when StatsOptions.Patterns is.
+	Patterns      []PatternCount `json:"patterns,omitempty"`
+	SemanticTypes []PatternCount `json:"semantic_types,omitempty"`
+}
+
+// NumericStats describes a numeric column. Mean and StdDev are exact
+// (Welford's online algorithm; StdDev is the sample standard deviation).
+// Quantiles come from a KLL sketch: exact for small columns, within about
+// 1% of rank on large ones.
+type NumericStats struct {
+	Count  int64   `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Sum    float64 `json:"sum"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	P25    float64 `json:"p25"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+	// Histogram is approximate on large columns (bins derive from the
+	// quantile sketch) and exact on small ones.
+	Histogram []HistogramBin `json:"histogram"`
 }
 
 // StatsResult is returned from Stats.
 type StatsResult struct {
-	TotalRows int64
-	Columns   []ColumnStats
+	TotalRows int64         `json:"total_rows"`
+	Columns   []ColumnStats `json:"columns"`
 }
 
 // Stats scans the CSV and returns row count plus per-column summary
-// (unique value count, empty cell count, and top 3 most frequent values).
+// (unique value count, empty cell count, most frequent values, value
+// lengths, histograms and, for numeric columns, descriptive statistics) in a
+// single pass.
 func Stats(ctx context.Context, opts StatsOptions) (StatsResult, error) {
 	var res StatsResult
 
+	headers, cols, rows, err := scanColumns(ctx, opts, nil)
+	if err != nil {
+		return res, err
+	}
+	res.TotalRows = rows
+	res.Columns = make([]ColumnStats, len(headers))
+	for i, name := range headers {
+		res.Columns[i] = cols[i].result(name)
+	}
+	return res, nil
+}
+
+// scanColumns validates opts, then streams opts.Input once, feeding each
+// cell to its column's accumulator. visit, when non-nil, also sees every row
+// (after the accumulators) so callers such as Profile can gather more in the
+// same pass.
+func scanColumns(ctx context.Context, opts StatsOptions, visit func(row []string, cols []*columnAcc)) ([]string, []*columnAcc, int64, error) {
 	if opts.Input == "" {
-		return res, fmt.Errorf("input is required")
+		return nil, nil, 0, fmt.Errorf("input is required")
 	}
 	if opts.Delimiter == 0 {
 		opts.Delimiter = ','
 	}
+	if opts.TopN <= 0 {
+		opts.TopN = DefaultTopN
+	}
+	if opts.Bins <= 0 {
+		opts.Bins = DefaultHistogramBins
+	}
+	switch opts.Histogram {
+	case "":
+		opts.Histogram = HistogramEqualWidth
+	case HistogramEqualWidth, HistogramQuantile:
+	default:
+		return nil, nil, 0, fmt.Errorf("unknown histogram kind %q (want %q or %q)", opts.Histogram, HistogramEqualWidth, HistogramQuantile)
+	}
 
 	total, err := CountDataRows(opts.Input, opts.Delimiter)
 	if err != nil {
-		return res, err
+		return nil, nil, 0, err
 	}
 
 	f, err := os.Open(opts.Input)
 	if err != nil {
-		return res, fmt.Errorf("open input: %w", err)
+		return nil, nil, 0, fmt.Errorf("open input: %w", err)
 	}
 	defer f.Close()
 
@@ -72,23 +166,18 @@ func Stats(ctx context.Context, opts StatsOptions) (StatsResult, error) {
 
 	headers, err := reader.Read()
 	if err != nil {
-		return res, fmt.Errorf("read headers: %w", err)
+		return nil, nil, 0, fmt.Errorf("read headers: %w", err)
 	}
 
-	type acc struct {
-		empty   int
-		uniques map[string]int
-		capped  bool
-	}
-	cols := make([]acc, len(headers))
+	cols := make([]*columnAcc, len(headers))
 	for i := range cols {
-		cols[i].uniques = make(map[string]int)
+		cols[i] = newColumnAcc(opts)
 	}
 
 	var processed int64
 	for {
 		if err := ctx.Err(); err != nil {
-			return res, err
+			return nil, nil, 0, err
 		}
 		row, err := reader.Read()
 		if err == io.EOF {
@@ -97,51 +186,197 @@ func Stats(ctx context.Context, opts StatsOptions) (StatsResult, error) {
 		if err != nil {
 			continue
 		}
-		res.TotalRows++
 		for i := range headers {
 			cell := ""
 			if i < len(row) {
-				cell = strings.TrimSpace(row[i])
-			}
-			if cell == "" {
-				cols[i].empty++
-				continue
-			}
-			if opts.MaxUnique > 0 && len(cols[i].uniques) >= opts.MaxUnique {
-				if _, exists := cols[i].uniques[cell]; exists {
-					cols[i].uniques[cell]++
-				} else {
-					cols[i].capped = true
-				}
-			} else {
-				cols[i].uniques[cell]++
+				cell = row[i]
 			}
+			cols[i].add(cell)
+		}
+		if visit != nil {
+			visit(row, cols)
 		}
 		processed++
 		safeProgress(opts.Progress, processed, total)
 	}
+	return headers, cols, processed, nil
+}
 
-	res.Columns = make([]ColumnStats, len(headers))
-	for i, name := range headers {
-		c := &cols[i]
-		sorted := make([]ValueCount, 0, len(c.uniques))
+// columnAcc accumulates the statistics for one column during the single
+// streaming pass.
+type columnAcc struct {
+	opts     StatsOptions
+	empty    int
+	nonEmpty int64
+	uniques  map[string]int // exact counts; nil once the sketches take over
+	capped   bool
+	hll      *hyperLogLog
+	hitters  *spaceSaving
+	minLen   int
+	maxLen   int
+	patterns *patternAcc // nil unless opts.Patterns
+	lengths  map[int]int64
+	classes  map[uint8]int64
+
+	// numeric accumulators; numeric stays true while every value parses
+	numeric  bool
+	count    int64
+	min, max float64
+	sum      float64
+	mean, m2 float64 // Welford running mean and sum of squared deviations
+	sketch   *kllSketch
+	// last holds the value parsed from the latest cell when lastOK is set,
+	// so per-row consumers (Profile's correlations) need not parse it again.
+	last   float64
+	lastOK bool
+}
+
+func newColumnAcc(opts StatsOptions) *columnAcc {
+	c := &columnAcc{
+		opts:    opts,
+		uniques: make(map[string]int),
+		lengths: make(map[int]int64),
+		classes: make(map[uint8]int64),
+		numeric: true,
+		sketch:  newKLLSketch(defaultKLLK),
+	}
+	if opts.Patterns {
+		c.patterns = newPatternAcc()
+	}
+	if opts.EstimateUnique {
+		c.spill()
+	}
+	return c
+}
+
+// spill replaces the exact counts with the cardinality and heavy-hitters
+// sketches, seeding both with what has been counted so far.
+func (c *columnAcc) spill() {
+	c.hll = newHyperLogLog()
+	for v := range c.uniques {
+		c.hll.add(v)
+	}
+	c.hitters = newSpaceSaving(defaultHeavyHitters)
+	c.hitters.seed(c.uniques)
+	c.uniques = nil
+}
+
+func (c *columnAcc) add(cell string) {
+	c.lastOK = false
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		c.empty++
+		return
+	}
+	c.nonEmpty++
+
+	if c.uniques != nil {
+		if _, exists := c.uniques[cell]; !exists && c.opts.MaxUnique > 0 && len(c.uniques) >= c.opts.MaxUnique {
+			c.capped = true
+			c.spill()
+		} else {
+			c.uniques[cell]++
+		}
+	}
+	if c.uniques == nil {
+		c.hll.add(cell)
+		c.hitters.add(cell)
+	}
+
+	if c.patterns != nil {
+		c.patterns.add(cell)
+	}
+
+	classes, n := charClasses(cell)
+	c.classes[classes]++
+	c.lengths[n]++
+	if c.nonEmpty == 1 || n < c.minLen {
+		c.minLen = n
+	}
+	if n > c.maxLen {
+		c.maxLen = n
+	}
+
+	if !c.numeric {
+		return
+	}
+	v, err := strconv.ParseFloat(cell, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		c.numeric = false
+		c.sketch = nil // free it; the column is text
+		return
+	}
+	c.count++
+	if c.count == 1 || v < c.min {
+		c.min = v
+	}
+	if c.count == 1 || v > c.max {
+		c.max = v
+	}
+	c.sum += v
+	delta := v - c.mean
+	c.mean += delta / float64(c.count)
+	c.m2 += delta * (v - c.mean)
+	c.sketch.add(v)
+	c.last, c.lastOK = v, true
+}
+
+func (c *columnAcc) result(name string) ColumnStats {
+	var sorted []ValueCount
+	unique := len(c.uniques)
+	if c.uniques != nil {
+		sorted = make([]ValueCount, 0, len(c.uniques))
 		for k, v := range c.uniques {
 			sorted = append(sorted, ValueCount{Value: k, Count: v})
 		}
-		sort.Slice(sorted, func(a, b int) bool {
+	} else {
+		sorted = c.hitters.counts()
+		unique = int(math.Round(c.hll.estimate()))
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].Count != sorted[b].Count {
 			return sorted[a].Count > sorted[b].Count
-		})
-		top := sorted
-		if len(top) > 3 {
-			top = top[:3]
 		}
-		res.Columns[i] = ColumnStats{
-			Name:         name,
-			Unique:       len(c.uniques),
-			UniqueCapped: c.capped,
-			Empty:        c.empty,
-			Top:          top,
+		return sorted[a].Value < sorted[b].Value
+	})
+	top := sorted
+	if len(top) > c.opts.TopN {
+		top = top[:c.opts.TopN]
+	}
+	cs := ColumnStats{
+		Name:         name,
+		Unique:       unique,
+		UniqueCapped: c.capped,
+		Empty:        c.empty,
+		Top:          top,
+		MinLength:    c.minLen,
+		MaxLength:    c.maxLen,
+
+		LengthHistogram: lengthHistogram(c.lengths, c.minLen, c.maxLen, c.opts.Bins),
+		ClassHistogram:  classHistogram(c.classes),
+	}
+	if c.patterns != nil {
+		cs.Patterns, cs.SemanticTypes = c.patterns.result(c.nonEmpty, c.opts.TopN)
+	}
+	if c.hll != nil {
+		cs.UniqueEstimated = true
+		cs.UniqueError = c.hll.relativeError()
+	}
+	if c.numeric && c.count > 0 {
+		ns := &NumericStats{
+			Count: c.count,
+			Min:   c.min,
+			Max:   c.max,
+			Sum:   c.sum,
+			Mean:  c.mean,
+		}
+		if c.count > 1 {
+			ns.StdDev = math.Sqrt(c.m2 / float64(c.count-1))
 		}
+		q := c.sketch.quantiles(0.25, 0.50, 0.75, 0.95, 0.99)
+		ns.P25, ns.P50, ns.P75, ns.P95, ns.P99 = q[0], q[1], q[2], q[3], q[4]
+		ns.Histogram = numericHistogram(c.sketch, c.count, c.min, c.max, c.opts.Histogram, c.opts.Bins)
+		cs.Numeric = ns
 	}
-	return res, nil
+	return cs
 }
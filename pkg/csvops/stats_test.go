@@ -2,7 +2,10 @@ package csvops
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,10 +56,11 @@ func TestStats_MaxUniqueCap(t *testing.T) {
 	if !col.UniqueCapped {
 		t.Error("expected UniqueCapped=true")
 	}
-	if col.Unique != 2 {
-		t.Errorf("Unique = %d, want 2 (capped)", col.Unique)
+	// Past the cap Unique is a HyperLogLog estimate, which is exact at this size.
+	if col.Unique != 5 || !col.UniqueEstimated || col.UniqueError <= 0 {
+		t.Errorf("Unique = %d (estimated=%v, error=%v), want 5 estimated", col.Unique, col.UniqueEstimated, col.UniqueError)
 	}
-	// 'a' was encountered before the cap hit, so its count should keep growing.
+	// 'a' was counted before the cap hit and keeps counting in the sketch.
 	if col.Top[0].Value != "a" || col.Top[0].Count != 3 {
 		t.Errorf("top[0] = %+v, want a/3", col.Top[0])
 	}
@@ -98,3 +102,171 @@ func TestStats_EmptyFileHeaderOnly(t *testing.T) {
 		t.Errorf("Columns = %d, want 2", len(res.Columns))
 	}
 }
+
+func TestStats_NumericSummary(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "price,name\n2,ab\n4,abcd\n4,\n4,a\n5,héllo\n5,x\n7,y\n9,z\n")
+
+	res, err := Stats(context.Background(), StatsOptions{Input: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := res.Columns[0].Numeric
+	if n == nil {
+		t.Fatal("price: Numeric = nil")
+	}
+	if n.Count != 8 || n.Min != 2 || n.Max != 9 || n.Sum != 40 || n.Mean != 5 {
+		t.Errorf("price = %+v", *n)
+	}
+	// sample stddev of 2,4,4,4,5,5,7,9 is sqrt(32/7)
+	if want := math.Sqrt(32.0 / 7); math.Abs(n.StdDev-want) > 1e-9 {
+		t.Errorf("StdDev = %v, want %v", n.StdDev, want)
+	}
+	if n.P50 != 4 || n.P25 != 4 || n.P99 != 9 {
+		t.Errorf("quantiles p25=%v p50=%v p99=%v", n.P25, n.P50, n.P99)
+	}
+
+	name := res.Columns[1]
+	if name.Numeric != nil {
+		t.Errorf("name: Numeric = %+v, want nil", name.Numeric)
+	}
+	if name.MinLength != 1 || name.MaxLength != 5 {
+		t.Errorf("name lengths = %d-%d, want 1-5 (runes, empty ignored)", name.MinLength, name.MaxLength)
+	}
+}
+
+func TestStats_MixedColumnIsNotNumeric(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "v\n1\n2\nn/a\n3\n")
+
+	res, err := Stats(context.Background(), StatsOptions{Input: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Columns[0].Numeric != nil {
+		t.Errorf("Numeric = %+v, want nil", res.Columns[0].Numeric)
+	}
+}
+
+func TestStats_EstimatedUniqueAndHeavyHitters(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	// 50,000 distinct ids, plus "hot" on every 10th row and "warm" on every 25th.
+	var b strings.Builder
+	b.WriteString("id\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&b, "id-%d\n", i)
+		if i%10 == 0 {
+			b.WriteString("hot\n")
+		}
+		if i%25 == 0 {
+			b.WriteString("warm\n")
+		}
+	}
+	writeCSV(t, in, b.String())
+
+	for _, opts := range []StatsOptions{
+		{Input: in, MaxUnique: 1000},
+		{Input: in, EstimateUnique: true},
+	} {
+		res, err := Stats(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		col := res.Columns[0]
+		if !col.UniqueEstimated {
+			t.Fatalf("%+v: UniqueEstimated = false", opts)
+		}
+		// 4 standard errors is a generous bound for a fixed hash.
+		if rel := math.Abs(float64(col.Unique)-50002) / 50002; rel > 4*col.UniqueError {
+			t.Errorf("%+v: Unique = %d, want ~50002 (±%.2f%%)", opts, col.Unique, 100*col.UniqueError)
+		}
+		if col.Top[0].Value != "hot" || col.Top[1].Value != "warm" {
+			t.Fatalf("%+v: Top = %+v", opts, col.Top[:2])
+		}
+		if c := col.Top[0]; c.Count < 5000 || c.Count-c.Error > 5000 {
+			t.Errorf("%+v: hot = %+v, want 5000 within its error bound", opts, c)
+		}
+	}
+}
+
+func TestStats_TopNDeterministicTies(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "k\nd\nc\nb\na\nb\nc\ne\n")
+
+	for i := 0; i < 5; i++ {
+		res, err := Stats(context.Background(), StatsOptions{Input: in, TopN: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := res.Columns[0].Top
+		want := []ValueCount{{"b", 2, 0}, {"c", 2, 0}, {"a", 1, 0}, {"d", 1, 0}}
+		if len(got) != len(want) {
+			t.Fatalf("Top = %+v", got)
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("Top = %+v, want %+v", got, want)
+			}
+		}
+	}
+}
+
+func TestStats_Histograms(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	var b strings.Builder
+	b.WriteString("n,code\n")
+	for i := 0; i < 100; i++ {
+		code := fmt.Sprintf("AB%d", i) // 3 or 4 characters
+		if i%4 == 0 {
+			code = "x y"
+		}
+		fmt.Fprintf(&b, "%d,%s\n", i, code)
+	}
+	writeCSV(t, in, b.String())
+
+	res, err := Stats(context.Background(), StatsOptions{Input: in, Bins: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := res.Columns[0].Numeric.Histogram
+	if len(h) != 4 || h[0].Low != 0 || h[3].High != 99 {
+		t.Fatalf("histogram = %+v", h)
+	}
+	// Edges 0, 24.75, 49.5, 74.25, 99 over 0..99.
+	for i, want := range []int64{25, 25, 25, 25} {
+		if h[i].Count != want {
+			t.Errorf("bin %d = %+v, want count %d", i, h[i], want)
+		}
+	}
+
+	q, err := Stats(context.Background(), StatsOptions{Input: in, Bins: 4, Histogram: HistogramQuantile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bin := range q.Columns[0].Numeric.Histogram {
+		if bin.Count < 24 || bin.Count > 26 {
+			t.Errorf("quantile bin %+v, want ~25", bin)
+		}
+	}
+
+	code := res.Columns[1]
+	var total int64
+	for _, bin := range code.LengthHistogram {
+		total += bin.Count
+	}
+	if total != 100 || len(code.LengthHistogram) != 2 || code.LengthHistogram[0].Label != "3" {
+		t.Errorf("length histogram = %+v", code.LengthHistogram)
+	}
+	if c := code.ClassHistogram; len(c) != 2 || c[0].Label != "letters+digits" || c[0].Count != 75 || c[1].Label != "letters+spaces" {
+		t.Errorf("class histogram = %+v", c)
+	}
+
+	if _, err := Stats(context.Background(), StatsOptions{Input: in, Histogram: "log"}); err == nil {
+		t.Error("expected error for unknown histogram kind")
+	}
+}
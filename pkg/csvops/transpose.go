@@ -0,0 +1,168 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// DefaultTransposeMaxBytes is the input size Transpose accepts by default.
+const DefaultTransposeMaxBytes = 16 << 20
+
+// TransposeOptions configures a Transpose operation.
+type TransposeOptions struct {
+	Input  string
+	Output io.Writer
+	// MaxBytes is the largest input Transpose accepts (default
+	// DefaultTransposeMaxBytes). The whole file is held in memory, so larger
+	// files are refused rather than risking running out of it.
+	MaxBytes  int64
+	Delimiter rune
+}
+
+// TransposeResult is returned from Transpose.
+type TransposeResult struct {
+	Rows    int `json:"rows"`    // input records, header included
+	Columns int `json:"columns"` // fields in the widest input record
+}
+
+// Transpose flips rows and columns: output record i holds field i of every
+// input record, the header included, so the header becomes the first
+// column. Short records are padded with empty fields.
+func Transpose(ctx context.Context, opts TransposeOptions) (TransposeResult, error) {
+	var res TransposeResult
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultTransposeMaxBytes
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	info, err := os.Stat(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	if info.Size() > opts.MaxBytes {
+		return res, fmt.Errorf("%s is %d bytes, over the %d byte transpose limit", opts.Input, info.Size(), opts.MaxBytes)
+	}
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = opts.Delimiter
+	r.FieldsPerRecord = -1
+
+	var records [][]string
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		records = append(records, rec)
+		res.Columns = max(res.Columns, len(rec))
+	}
+	res.Rows = len(records)
+
+	writer := csv.NewWriter(opts.Output)
+	writer.Comma = opts.Delimiter
+	out := make([]string, len(records))
+	for i := 0; i < res.Columns; i++ {
+		for j, rec := range records {
+			out[j] = cell(rec, i)
+		}
+		if err := writer.Write(out); err != nil {
+			return res, fmt.Errorf("write row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return res, fmt.Errorf("writer: %w", err)
+	}
+	return res, nil
+}
+
+// errStopRows ends a readRows walk early.
+var errStopRows = errors.New("stop reading rows")
+
+// ExplodeRowOptions configures an ExplodeRow operation.
+type ExplodeRowOptions struct {
+	Input     string
+	Row       int64 // 1-based data row, header excluded
+	Delimiter rune
+}
+
+// RecordField is one column of an exploded record.
+type RecordField struct {
+	Column string `json:"column"`
+	Value  string `json:"value"`
+}
+
+// ExplodeRowResult is returned from ExplodeRow.
+type ExplodeRowResult struct {
+	Row    int64         `json:"row"`
+	Fields []RecordField `json:"fields"`
+}
+
+// ExplodeRow returns a single record as column/value pairs, which reads far
+// better than a table for wide files. The file is streamed up to the row.
+// Fields past the end of the header are named column_N.
+func ExplodeRow(ctx context.Context, opts ExplodeRowOptions) (ExplodeRowResult, error) {
+	res := ExplodeRowResult{Row: opts.Row}
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Row < 1 {
+		return res, fmt.Errorf("row must be 1 or more (got %d)", opts.Row)
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	var header []string
+	var n int64
+	err := readRows(ctx, opts.Input, opts.Delimiter, func(h []string) error {
+		header = h
+		return nil
+	}, func(row []string) error {
+		n++
+		if n < opts.Row {
+			return nil
+		}
+		for i := range max(len(header), len(row)) {
+			name := "column_" + strconv.Itoa(i+1)
+			if i < len(header) {
+				name = header[i]
+			}
+			res.Fields = append(res.Fields, RecordField{Column: name, Value: cell(row, i)})
+		}
+		return errStopRows
+	})
+	if err == errStopRows {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	return res, fmt.Errorf("row %d is past the end of the file (%d rows)", opts.Row, n)
+}
@@ -0,0 +1,52 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranspose(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "key,value,note\nhost,localhost\nport,8080,\"tcp,udp\"\n")
+
+	var buf bytes.Buffer
+	res, err := Transpose(context.Background(), TransposeOptions{Input: in, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "key,host,port\nvalue,localhost,8080\nnote,,\"tcp,udp\"\n"
+	if buf.String() != want || res.Rows != 3 || res.Columns != 3 {
+		t.Errorf("output:\n%s\nres = %+v", buf.String(), res)
+	}
+
+	_, err = Transpose(context.Background(), TransposeOptions{Input: in, Output: &buf, MaxBytes: 10})
+	if err == nil || !strings.Contains(err.Error(), "transpose limit") {
+		t.Errorf("MaxBytes: err = %v", err)
+	}
+}
+
+func TestExplodeRow(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	writeCSV(t, in, "id,name\n1,a\n2,\"b\nc\",extra\n")
+
+	res, err := ExplodeRow(context.Background(), ExplodeRowOptions{Input: in, Row: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordField{{"id", "2"}, {"name", "b\nc"}, {"column_3", "extra"}}
+	if len(res.Fields) != len(want) {
+		t.Fatalf("fields = %v", res.Fields)
+	}
+	for i := range want {
+		if res.Fields[i] != want[i] {
+			t.Errorf("field %d = %v, want %v", i, res.Fields[i], want[i])
+		}
+	}
+
+	if _, err := ExplodeRow(context.Background(), ExplodeRowOptions{Input: in, Row: 3}); err == nil {
+		t.Error("expected an error past the end")
+	}
+}
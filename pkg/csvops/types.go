@@ -0,0 +1,105 @@
+package csvops
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType is the storage type inferred for (or declared on) a column. The
+// names follow the Frictionless Table Schema.
+type ColumnType string
+
+const (
+	TypeEmpty    ColumnType = "empty" // no non-empty values
+	TypeInteger  ColumnType = "integer"
+	TypeNumber   ColumnType = "number"
+	TypeBoolean  ColumnType = "boolean"
+	TypeDate     ColumnType = "date"
+	TypeDatetime ColumnType = "datetime"
+	TypeString   ColumnType = "string"
+)
+
+// dateLayouts and datetimeLayouts are the formats recognized when inferring
+// date and datetime columns.
+var (
+	dateLayouts     = []string{"2006-01-02", "2006/01/02", "02-Jan-2006", "Jan 2, 2006"}
+	datetimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+)
+
+func isInteger(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+func isNumber(v string) bool {
+	f, err := strconv.ParseFloat(v, 64)
+	return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func isBoolean(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no":
+		return true
+	}
+	return false
+}
+
+func matchesLayout(v string, layouts []string) bool {
+	for _, l := range layouts {
+		if _, err := time.Parse(l, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isDate(v string) bool     { return matchesLayout(v, dateLayouts) }
+func isDatetime(v string) bool { return matchesLayout(v, datetimeLayouts) }
+
+// typeChecks lists the candidate types from most to least specific.
+var typeChecks = []struct {
+	typ   ColumnType
+	check func(string) bool
+}{
+	{TypeInteger, isInteger},
+	{TypeNumber, isNumber},
+	{TypeBoolean, isBoolean},
+	{TypeDate, isDate},
+	{TypeDatetime, isDatetime},
+}
+
+// typeAcc narrows a column's type as values stream past: each bit in
+// candidates is a typeChecks entry every value so far has passed.
+type typeAcc struct {
+	seen       bool
+	candidates uint8
+}
+
+func (t *typeAcc) add(v string) {
+	if v == "" {
+		return
+	}
+	if !t.seen {
+		t.seen = true
+		t.candidates = 1<<len(typeChecks) - 1
+	}
+	for i, tc := range typeChecks {
+		if t.candidates&(1<<i) != 0 && !tc.check(v) {
+			t.candidates &^= 1 << i
+		}
+	}
+}
+
+func (t *typeAcc) result() ColumnType {
+	if !t.seen {
+		return TypeEmpty
+	}
+	for i, tc := range typeChecks {
+		if t.candidates&(1<<i) != 0 {
+			return tc.typ
+		}
+	}
+	return TypeString
+}
@@ -0,0 +1,128 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// UnpivotOptions configures an Unpivot operation.
+type UnpivotOptions struct {
+	Input  string
+	Output io.Writer
+	// IDColumns are copied to every output row.
+	IDColumns []string
+	// ValueColumns are turned into name/value pairs. Empty means every
+	// column not in IDColumns.
+	ValueColumns []string
+	// NameColumn and ValueColumn name the two new columns (default "name"
+	// and "value").
+	NameColumn  string
+	ValueColumn string
+	SkipEmpty   bool // leave out pairs whose value is empty
+	Delimiter   rune
+	Progress    Progress
+}
+
+// UnpivotResult is returned from Unpivot.
+type UnpivotResult struct {
+	TotalRows   int64    `json:"total_rows"`
+	RowsWritten int64    `json:"rows_written"`
+	Columns     []string `json:"columns"` // the unpivoted columns
+}
+
+// Unpivot (melt) reshapes wide data to long: every input row becomes one
+// output row per value column, holding the ID columns, the value column's
+// name and its value. It streams in constant memory.
+func Unpivot(ctx context.Context, opts UnpivotOptions) (UnpivotResult, error) {
+	var res UnpivotResult
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Output == nil {
+		return res, fmt.Errorf("output writer is required")
+	}
+	if opts.NameColumn == "" {
+		opts.NameColumn = "name"
+	}
+	if opts.ValueColumn == "" {
+		opts.ValueColumn = "value"
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	total, err := CountDataRows(opts.Input, opts.Delimiter)
+	if err != nil {
+		return res, err
+	}
+	res.TotalRows = total
+
+	writer := csv.NewWriter(opts.Output)
+	writer.Comma = opts.Delimiter
+
+	var idIdx, valIdx []int
+	var out []string
+	var done int64
+	err = readRows(ctx, opts.Input, opts.Delimiter, func(h []string) error {
+		var err error
+		if idIdx, err = resolveKeyIndexes(h, opts.IDColumns, true); err != nil {
+			return err
+		}
+		if len(opts.ValueColumns) > 0 {
+			if valIdx, err = resolveKeyIndexes(h, opts.ValueColumns, true); err != nil {
+				return err
+			}
+		} else {
+			for i := range h {
+				if !slices.Contains(idIdx, i) {
+					valIdx = append(valIdx, i)
+				}
+			}
+		}
+		if len(valIdx) == 0 {
+			return fmt.Errorf("no columns to unpivot")
+		}
+		for _, i := range valIdx {
+			res.Columns = append(res.Columns, h[i])
+		}
+		for _, i := range idIdx {
+			out = append(out, h[i])
+		}
+		out = append(out, opts.NameColumn, opts.ValueColumn)
+		if err := writer.Write(out); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+		return nil
+	}, func(row []string) error {
+		done++
+		safeProgress(opts.Progress, done, total)
+		for k, i := range idIdx {
+			out[k] = cell(row, i)
+		}
+		for k, i := range valIdx {
+			v := cell(row, i)
+			if opts.SkipEmpty && v == "" {
+				continue
+			}
+			out[len(idIdx)], out[len(idIdx)+1] = res.Columns[k], v
+			if err := writer.Write(out); err != nil {
+				return err
+			}
+			res.RowsWritten++
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return res, fmt.Errorf("writer: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,319 @@
+package csvops
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ValidateOptions configures a Validate operation.
+type ValidateOptions struct {
+	Input     string
+	Schema    *Schema
+	Delimiter rune
+	// ValidOutput and InvalidOutput, when set, receive the header and the rows
+	// that passed or failed. Invalid rows get an extra "_errors" column
+	// listing their violations.
+	ValidOutput   io.Writer
+	InvalidOutput io.Writer
+	// MaxViolations caps how many violations are kept in the result
+	// (0 = all). Counting continues past the cap.
+	MaxViolations int
+	// OnViolation, if set, is called for every violation as it is found.
+	OnViolation func(Violation)
+	Progress    Progress
+}
+
+// Violation is one broken schema rule. Row is the 1-based data row, or 0 for
+// problems with the header.
+type Violation struct {
+	Row     int64  `json:"row"`
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidateResult is returned from Validate.
+type ValidateResult struct {
+	Rows           int64       `json:"rows"`
+	ValidRows      int64       `json:"valid_rows"`
+	InvalidRows    int64       `json:"invalid_rows"`
+	ViolationCount int64       `json:"violation_count"`
+	Violations     []Violation `json:"violations"`
+	Valid          bool        `json:"valid"`
+}
+
+// InvalidErrorsColumn is appended to rows written to InvalidOutput.
+const InvalidErrorsColumn = "_errors"
+
+// Validate streams the input CSV and checks it against opts.Schema: declared
+// columns must exist, and every cell must meet its field's type and
+// constraints. unique and primaryKey checks remember every value seen, so
+// their memory grows with the number of distinct keys. Cross-column rules
+// are skipped for rows where either side is missing or mistyped.
+func Validate(ctx context.Context, opts ValidateOptions) (ValidateResult, error) {
+	var res ValidateResult
+
+	if opts.Input == "" {
+		return res, fmt.Errorf("input is required")
+	}
+	if opts.Schema == nil {
+		return res, fmt.Errorf("schema is required")
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	cs, err := opts.Schema.compile()
+	if err != nil {
+		return res, err
+	}
+
+	total, err := CountDataRows(opts.Input, opts.Delimiter)
+	if err != nil {
+		return res, err
+	}
+
+	f, err := os.Open(opts.Input)
+	if err != nil {
+		return res, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comma = opts.Delimiter
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err != nil {
+		return res, fmt.Errorf("read header: %w", err)
+	}
+
+	report := func(v Violation) {
+		res.ViolationCount++
+		if opts.MaxViolations <= 0 || len(res.Violations) < opts.MaxViolations {
+			res.Violations = append(res.Violations, v)
+		}
+		if opts.OnViolation != nil {
+			opts.OnViolation(v)
+		}
+	}
+
+	position := make(map[string]int, len(headers))
+	for i, h := range headers {
+		if _, dup := position[h]; !dup {
+			position[h] = i
+		}
+	}
+	for _, fld := range cs.fields {
+		if i, ok := position[fld.Name]; ok {
+			fld.index = i
+		} else {
+			report(Violation{Column: fld.Name, Rule: "missing-column", Message: "column is not in the header"})
+		}
+	}
+	pkIdx := make([]int, 0, len(cs.pk))
+	for _, i := range cs.pk {
+		if cs.fields[i].index >= 0 {
+			pkIdx = append(pkIdx, cs.fields[i].index)
+		}
+	}
+	if len(pkIdx) == 0 || len(pkIdx) < len(cs.pk) {
+		pkIdx = nil
+	}
+
+	newWriter := func(w io.Writer, extra ...string) (*csv.Writer, error) {
+		if w == nil {
+			return nil, nil
+		}
+		cw := csv.NewWriter(w)
+		cw.Comma = opts.Delimiter
+		if err := cw.Write(append(append([]string{}, headers...), extra...)); err != nil {
+			return nil, fmt.Errorf("write header: %w", err)
+		}
+		return cw, nil
+	}
+	validW, err := newWriter(opts.ValidOutput)
+	if err != nil {
+		return res, err
+	}
+	invalidW, err := newWriter(opts.InvalidOutput, InvalidErrorsColumn)
+	if err != nil {
+		return res, err
+	}
+
+	unique := make([]map[string]int64, len(cs.fields))
+	for i, fld := range cs.fields {
+		if fld.Constraints.Unique && fld.index >= 0 {
+			unique[i] = make(map[string]int64)
+		}
+	}
+	var pkSeen map[string]int64
+	if pkIdx != nil {
+		pkSeen = make(map[string]int64)
+	}
+
+	values := make([]cellValue, len(cs.fields))
+	present := make([]bool, len(cs.fields))
+	var rowErrs []Violation
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		res.Rows++
+		n := res.Rows
+		rowErrs = rowErrs[:0]
+		add := func(v Violation) {
+			v.Row = n
+			rowErrs = append(rowErrs, v)
+		}
+
+		if err != nil {
+			add(Violation{Rule: "malformed", Message: err.Error()})
+		} else {
+			if len(row) != len(headers) {
+				add(Violation{Rule: "field-count", Message: fmt.Sprintf("has %d fields, header has %d", len(row), len(headers))})
+			}
+			for i, fld := range cs.fields {
+				present[i] = false
+				if fld.index < 0 {
+					continue
+				}
+				v := ""
+				if fld.index < len(row) {
+					v = row[fld.index]
+				}
+				if cs.missing[v] {
+					if fld.Constraints.Required {
+						add(Violation{Column: fld.Name, Value: v, Rule: "required", Message: "value is missing"})
+					}
+					continue
+				}
+				val, errs := fld.checkCell(v)
+				for _, e := range errs {
+					add(e)
+				}
+				if len(errs) == 0 || errs[0].Rule != "type" {
+					values[i], present[i] = val, true
+				}
+				if seen := unique[i]; seen != nil {
+					if first, dup := seen[v]; dup {
+						add(Violation{Column: fld.Name, Value: v, Rule: "unique", Message: fmt.Sprintf("duplicate of row %d", first)})
+					} else {
+						seen[v] = n
+					}
+				}
+			}
+			if pkSeen != nil {
+				checkPrimaryKey(row, pkIdx, cs, headers, pkSeen, n, add)
+			}
+			for _, r := range cs.rules {
+				ri := cs.fields[r.right].index
+				if !present[r.left] || ri < 0 || ri >= len(row) || cs.missing[row[ri]] {
+					continue
+				}
+				rv := row[ri]
+				right, err := cs.fields[r.left].parse(rv)
+				if err != nil {
+					continue
+				}
+				if !ruleHolds(compareValues(values[r.left], right), r.Op) {
+					add(Violation{
+						Column:  r.Left,
+						Value:   values[r.left].str,
+						Rule:    "rule",
+						Message: fmt.Sprintf("%s failed (%s is %q)", r.label(), r.Right, rv),
+					})
+				}
+			}
+		}
+
+		for _, v := range rowErrs {
+			report(v)
+		}
+		if len(rowErrs) == 0 {
+			res.ValidRows++
+			if validW != nil {
+				if err := validW.Write(row); err != nil {
+					return res, fmt.Errorf("write valid row: %w", err)
+				}
+			}
+		} else {
+			res.InvalidRows++
+			if invalidW != nil {
+				msgs := make([]string, len(rowErrs))
+				for i, v := range rowErrs {
+					msgs[i] = v.Rule
+					if v.Column != "" {
+						msgs[i] = v.Column + ": " + v.Rule
+					}
+				}
+				if err := invalidW.Write(append(row, strings.Join(msgs, "; "))); err != nil {
+					return res, fmt.Errorf("write invalid row: %w", err)
+				}
+			}
+		}
+		safeProgress(opts.Progress, res.Rows, total)
+	}
+
+	for _, w := range []*csv.Writer{validW, invalidW} {
+		if w == nil {
+			continue
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return res, fmt.Errorf("flush output: %w", err)
+		}
+	}
+	res.Valid = res.ViolationCount == 0
+	return res, nil
+}
+
+// checkPrimaryKey reports primary keys that have a missing part or repeat an
+// earlier row's key.
+func checkPrimaryKey(row []string, idx []int, cs *compiledSchema, headers []string, seen map[string]int64, n int64, add func(Violation)) {
+	names := make([]string, len(idx))
+	for i, c := range idx {
+		names[i] = headers[c]
+		if c >= len(row) || cs.missing[row[c]] {
+			add(Violation{Column: headers[c], Rule: "primary-key", Message: "primary key value is missing"})
+			return
+		}
+	}
+	key := BuildDedupeKey(row, idx, true)
+	if first, dup := seen[key]; dup {
+		add(Violation{
+			Column:  strings.Join(names, ","),
+			Value:   key,
+			Rule:    "primary-key",
+			Message: fmt.Sprintf("duplicate of row %d", first),
+		})
+		return
+	}
+	seen[key] = n
+}
+
+func ruleHolds(cmp int, op string) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package csvops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "fields": [
+    {"name": "id", "type": "integer", "constraints": {"required": true}},
+    {"name": "email", "constraints": {"pattern": "[^@]+@[^@]+", "unique": true}},
+    {"name": "status", "constraints": {"enum": ["active", "closed"]}},
+    {"name": "amount", "type": "number", "constraints": {"minimum": 0, "maximum": 1000}},
+    {"name": "start", "type": "date", "format": "%d/%m/%Y"},
+    {"name": "end", "type": "date", "format": "%d/%m/%Y", "constraints": {"minimum": "01/01/2020"}}
+  ],
+  "primaryKey": "id",
+  "missingValues": ["", "NA"],
+  "rules": [{"name": "end-after-start", "left": "end", "op": ">=", "right": "start"}]
+}`
+
+func loadTestSchema(t *testing.T, dir, content string) *Schema {
+	t.Helper()
+	path := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidate_ReportsEveryViolation(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id,email,status,amount,start,end\n"+
+		"1,a@x.com,active,10.5,01/02/2024,03/02/2024\n"+ // valid
+		"2,bad,pending,-1,01/02/2024,01/01/2024\n"+ // pattern, enum, minimum, rule
+		"1,a@x.com,NA,abc,1/2/2024,NA\n"+ // primary key, unique, type x2
+		",c@x.com,closed,5,,\n") // required, primary key missing
+
+	var valid, invalid bytes.Buffer
+	res, err := Validate(context.Background(), ValidateOptions{
+		Input:         in,
+		Schema:        loadTestSchema(t, dir, testSchema),
+		ValidOutput:   &valid,
+		InvalidOutput: &invalid,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || res.Rows != 4 || res.ValidRows != 1 || res.InvalidRows != 3 {
+		t.Fatalf("res = %+v", res)
+	}
+
+	var got []string
+	for _, v := range res.Violations {
+		got = append(got, fmt.Sprintf("%d %s %s", v.Row, v.Column, v.Rule))
+	}
+	want := []string{
+		"2 email pattern",
+		"2 status enum",
+		"2 amount minimum",
+		"2 end rule",
+		"3 email unique",
+		"3 amount type",
+		"3 start type",
+		"3 id primary-key",
+		"4 id required",
+		"4 id primary-key",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if res.ViolationCount != int64(len(want)) {
+		t.Errorf("count = %d", res.ViolationCount)
+	}
+
+	if valid.String() != "id,email,status,amount,start,end\n1,a@x.com,active,10.5,01/02/2024,03/02/2024\n" {
+		t.Errorf("valid output = %q", valid.String())
+	}
+	lines := strings.Split(strings.TrimSpace(invalid.String()), "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], ",_errors") {
+		t.Fatalf("invalid output = %q", invalid.String())
+	}
+	if !strings.HasSuffix(lines[1], ",email: pattern; status: enum; amount: minimum; end: rule") {
+		t.Errorf("invalid row = %q", lines[1])
+	}
+}
+
+func TestValidate_MissingColumnAndCap(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "id\nx\ny\nz\n")
+
+	schema := loadTestSchema(t, dir, `{"fields": [{"name": "id", "type": "integer"}, {"name": "name", "constraints": {"required": true}}]}`)
+	res, err := Validate(context.Background(), ValidateOptions{Input: in, Schema: schema, MaxViolations: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ViolationCount != 4 || len(res.Violations) != 2 {
+		t.Fatalf("count = %d kept = %d", res.ViolationCount, len(res.Violations))
+	}
+	if v := res.Violations[0]; v.Row != 0 || v.Column != "name" || v.Rule != "missing-column" {
+		t.Errorf("first = %+v", v)
+	}
+}
+
+func TestValidate_ISODateBoundsWithCustomFormat(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "day,at\n15/06/2024,2024/06/15 10:00\n31/12/2023,2025/01/01 00:00\n")
+
+	schema := loadTestSchema(t, dir, `{"fields": [
+    {"name": "day", "type": "date", "format": "%d/%m/%Y", "constraints": {"minimum": "2024-01-01"}},
+    {"name": "at", "type": "datetime", "format": "%Y/%m/%d %H:%M", "constraints": {"maximum": "2024-12-31T23:59:59Z"}}
+  ]}`)
+	res, err := Validate(context.Background(), ValidateOptions{Input: in, Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range res.Violations {
+		got = append(got, fmt.Sprintf("%d %s %s", v.Row, v.Column, v.Rule))
+	}
+	if want := "2 day minimum|2 at maximum"; strings.Join(got, "|") != want {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+}
+
+func TestValidate_StringFormats(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	writeCSV(t, in, "email,site,id,blob\n"+
+		"a@x.com,https://x.com/a,123e4567-e89b-12d3-a456-426614174000,aGk=\n"+
+		"a.x.com,x.com,123e4567,aGk\n")
+
+	schema := loadTestSchema(t, dir, `{"fields": [
+    {"name": "email", "format": "email"},
+    {"name": "site", "format": "uri"},
+    {"name": "id", "format": "uuid"},
+    {"name": "blob", "format": "binary"}
+  ]}`)
+	res, err := Validate(context.Background(), ValidateOptions{Input: in, Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range res.Violations {
+		got = append(got, fmt.Sprintf("%d %s %s", v.Row, v.Column, v.Rule))
+	}
+	if want := "2 email type|2 site type|2 id type|2 blob type"; strings.Join(got, "|") != want {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+}
+
+func TestLoadSchema_Errors(t *testing.T) {
+	for _, content := range []string{
+		`{"fields": []}`,
+		`{"fields": [{"name": "a", "type": "decimal"}]}`,
+		`{"fields": [{"name": "a", "constraints": {"pattern": "("}}]}`,
+		`{"fields": [{"name": "a"}], "primaryKey": ["b"]}`,
+		`{"fields": [{"name": "a"}], "rules": [{"left": "a", "op": "~", "right": "a"}]}`,
+		`{"fields": [{"name": "a", "type": "integer", "constraints": {"minimum": "x"}}]}`,
+		`{"fields": [{"name": "a", "format": "hostname"}]}`,
+		`{"fields": [{"name": "a", "type": "integer", "format": "email"}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "s.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSchema(path); err == nil {
+			t.Errorf("expected error for %s", content)
+		}
+	}
+}